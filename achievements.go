@@ -0,0 +1,88 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+)
+
+// RunStats are counted up over a single run of the game, from starting until
+// game over
+type RunStats struct {
+	Ticks     int // spent playing
+	Shots     int
+	Hits      int // shots that destroyed at least one asteroid
+	Kills     int // asteroids destroyed by the player
+	MoonKills int // asteroids destroyed by the Moon
+	Waves     int // survived
+}
+
+// Accuracy is the fraction of shots that destroyed something
+func (s RunStats) Accuracy() float64 {
+	if s.Shots == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Shots)
+}
+
+// An Achievement is a goal that stays unlocked once the player reaches it
+type Achievement struct {
+	ID       string // kept in the save file, never change it
+	Name     string
+	Unlocked func(RunStats) bool
+}
+
+// achievements are all the achievements there are, in the order they're listed
+var achievements = []Achievement{
+	{"first-blood", "FIRST BLOOD", func(s RunStats) bool {
+		return s.Kills > 0
+	}},
+	{"survivor", "SURVIVOR", func(s RunStats) bool {
+		return s.Ticks >= 5*60*ebiten.MaxTPS()
+	}},
+	{"sharpshooter", "SHARPSHOOTER", func(s RunStats) bool {
+		return s.Shots >= 20 && s.Accuracy() >= 0.9
+	}},
+	{"moon-assist", "MOON ASSIST", func(s RunStats) bool {
+		return s.MoonKills > 0
+	}},
+}
+
+// UnlockAchievements marks any achievements the stats have earned as unlocked
+// and returns the ones that weren't already
+func (s *SaveFile) UnlockAchievements(stats RunStats) []Achievement {
+	var unlocked []Achievement
+	for _, a := range achievements {
+		if !s.HasAchievement(a.ID) && a.Unlocked(stats) {
+			s.Achievements = append(s.Achievements, a.ID)
+			unlocked = append(unlocked, a)
+		}
+	}
+	return unlocked
+}
+
+// HasAchievement reports whether the achievement with ID id is unlocked
+func (s *SaveFile) HasAchievement(id string) bool {
+	for _, v := range s.Achievements {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// drawAchievements lists all the achievements and which are unlocked
+func drawAchievements(screen *ebiten.Image, save *SaveFile, face font.Face, width, height int) {
+	lines := make([]string, 0, len(achievements))
+	for _, a := range achievements {
+		mark := "[ ]"
+		if save.HasAchievement(a.ID) {
+			mark = "[X]"
+		}
+		lines = append(lines, mark+" "+a.Name)
+	}
+	drawPanel(screen, face, width, height, "ACHIEVEMENTS", lines)
+}
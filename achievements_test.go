@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestAchievementConditions(t *testing.T) {
+	minutes := func(m int) int { return m * 60 * ebiten.MaxTPS() }
+	tests := []struct {
+		id    string
+		stats RunStats
+		want  bool
+	}{
+		{"first-blood", RunStats{}, false},
+		{"first-blood", RunStats{Shots: 1, Hits: 1, Kills: 1}, true},
+		{"first-blood", RunStats{MoonKills: 3}, false},
+		{"survivor", RunStats{Ticks: minutes(5) - 1}, false},
+		{"survivor", RunStats{Ticks: minutes(5)}, true},
+		{"sharpshooter", RunStats{Shots: 10, Hits: 10}, false},
+		{"sharpshooter", RunStats{Shots: 20, Hits: 17}, false},
+		{"sharpshooter", RunStats{Shots: 20, Hits: 18}, true},
+		{"moon-assist", RunStats{Kills: 5}, false},
+		{"moon-assist", RunStats{MoonKills: 1}, true},
+	}
+	for _, tt := range tests {
+		save := &SaveFile{}
+		save.UnlockAchievements(tt.stats)
+		if got := save.HasAchievement(tt.id); got != tt.want {
+			t.Errorf("%s with %+v unlocked %v, want %v", tt.id, tt.stats, got, tt.want)
+		}
+	}
+}
+
+func TestUnlockAchievementsOnce(t *testing.T) {
+	save := &SaveFile{}
+	stats := RunStats{Kills: 1, MoonKills: 1}
+	if got := len(save.UnlockAchievements(stats)); got != 2 {
+		t.Errorf("unlocked %d achievements, want 2", got)
+	}
+	if got := len(save.UnlockAchievements(stats)); got != 0 {
+		t.Errorf("unlocked %d achievements again", got)
+	}
+	if len(save.Achievements) != 2 {
+		t.Errorf("save has achievements %v", save.Achievements)
+	}
+}
+
+func TestRecordStreak(t *testing.T) {
+	save := &SaveFile{}
+	if got := save.RecordStreak(2); len(got) != 0 {
+		t.Errorf("rewards %v for a streak of 2", got)
+	}
+	if got := save.RecordStreak(6); len(got) != 2 || got[0].Cosmetic != "green" || got[1].Cosmetic != "blue" {
+		t.Errorf("rewards %v for a streak of 6, want green and blue", got)
+	}
+	if got := save.RecordStreak(4); len(got) != 0 || save.BestStreak != 6 {
+		t.Errorf("shorter streak gave rewards %v and best %d", got, save.BestStreak)
+	}
+	if !save.HasCosmetic("blue") || save.HasCosmetic("gold") {
+		t.Errorf("cosmetics %v, want blue but not gold", save.Cosmetics)
+	}
+	if c := save.CrosshairColour("gold"); c != crosshairColours["white"] {
+		t.Errorf("locked gold crosshair is %v, want white", c)
+	}
+	if c := save.CrosshairColour("blue"); c != crosshairColours["blue"] {
+		t.Errorf("unlocked blue crosshair is %v", c)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// angleBetween is how far apart two bearings are, from 0 to Pi, whichever way
+// round is shorter
+func angleBetween(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 2*math.Pi)
+	if d > math.Pi {
+		d = 2*math.Pi - d
+	}
+	return d
+}
+
+// InTurretArc reports whether a point is in the arc the Earth's turret can
+// fire into, which is ArcDegrees wide and turns with the Earth, or always
+// when the turret arc is off
+func (g *Game) InTurretArc(p image.Point) bool {
+	if !TurretArc {
+		return true
+	}
+	bearing := math.Atan2(float64(p.Y-g.Earth.Center.Y), float64(p.X-g.Earth.Center.X))
+	return angleBetween(bearing, g.Rotation) <= ArcDegrees*math.Pi/360
+}
+
+// drawTurretArc rings the Earth with a dotted warning line over the part the
+// turret can't fire into
+func drawTurretArc(screen *ebiten.Image, g *Game) {
+	const segments = 60
+	if !TurretArc || g.Wave == 0 {
+		return
+	}
+	r := g.Earth.Radius + 16
+	cx, cy := float64(g.Earth.Center.X), float64(g.Earth.Center.Y)
+	for i := 0; i < segments; i += 2 {
+		a, b := 2*math.Pi*float64(i)/segments, 2*math.Pi*float64(i+1)/segments
+		if angleBetween((a+b)/2, g.Rotation) <= ArcDegrees*math.Pi/360 {
+			continue
+		}
+		ebitenutil.DrawLine(screen, cx+r*math.Cos(a), cy+r*math.Sin(a), cx+r*math.Cos(b), cy+r*math.Sin(b), hud.Warning)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// beltJitter is how far out of line each asteroid in a belt can be, as a
+// fraction of the gap between them
+const beltJitter = 0.2
+
+// BeltWave reports whether the current wave is an asteroid belt
+func (g *Game) BeltWave() bool {
+	return BeltEvery > 0 && g.Wave > 0 && g.Wave%BeltEvery == 0
+}
+
+// formBelt arranges asteroids into an evenly spaced ring at distance, all
+// falling slowly together
+func formBelt(as Asteroids, r *rand.Rand, distance float64) {
+	gap := 2 * math.Pi / float64(len(as))
+	start := r.Float64() * gap
+	for i, v := range as {
+		v.Angle = start + gap*(float64(i)+(r.Float64()*2-1)*beltJitter)
+		v.Distance = distance
+		v.Speed = BeltSpeed
+	}
+}
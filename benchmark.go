@@ -0,0 +1,77 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"runtime"
+	"time"
+)
+
+// benchmarkWave is the fixed scenario run by the -benchmark flag, keep it the
+// same so results can be compared between changes
+var benchmarkWave = struct {
+	Seed      int64
+	Asteroids int
+	MaxTicks  int
+}{
+	Seed:      1,
+	Asteroids: 80,
+	MaxTicks:  5000,
+}
+
+// runBenchmark plays the benchmark wave without a window, as fast as possible
+// and without any player input, then writes timing and allocation statistics
+// to w as key=value lines
+func runBenchmark(w io.Writer) {
+	SaveFileName = "" // don't touch the player's save
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(benchmarkWave.Seed))))
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.HowMany = benchmarkWave.Asteroids
+	g.Sounds = &Sounds{} // silent
+	if err := g.State.Transition(StatePlaying); err != nil {
+		log.Fatal(err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	ticks := 0
+	for ticks < benchmarkWave.MaxTicks && g.Asteroids.Alive() {
+		if err := g.Update(); err != nil {
+			break
+		}
+		ticks++
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	result := "timeout"
+	if g.Earth.Impacted {
+		result = "gameover"
+	} else if !g.Asteroids.Alive() {
+		result = "cleared"
+	}
+
+	fmt.Fprintf(w, "seed=%d\n", benchmarkWave.Seed)
+	fmt.Fprintf(w, "asteroids=%d\n", benchmarkWave.Asteroids)
+	fmt.Fprintf(w, "result=%s\n", result)
+	fmt.Fprintf(w, "ticks=%d\n", ticks)
+	fmt.Fprintf(w, "remaining=%d\n", g.Count)
+	fmt.Fprintf(w, "elapsed=%s\n", elapsed)
+	if ticks > 0 {
+		fmt.Fprintf(w, "ns_per_tick=%d\n", elapsed.Nanoseconds()/int64(ticks))
+		fmt.Fprintf(w, "allocs_per_tick=%d\n", (after.Mallocs-before.Mallocs)/uint64(ticks))
+		fmt.Fprintf(w, "bytes_per_tick=%d\n", (after.TotalAlloc-before.TotalAlloc)/uint64(ticks))
+	}
+}
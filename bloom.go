@@ -0,0 +1,58 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// glowSize is the width and height of the pre-rendered glow sprite
+const glowSize = 64
+
+// glowSprite is the soft glow drawn behind explosions, made the first time
+// it's needed
+var glowSprite *ebiten.Image
+
+// glowImage renders a white spot that fades out smoothly towards its edges
+func glowImage(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	r := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			d := math.Hypot(float64(x)+0.5-r, float64(y)+0.5-r) / r
+			a := uint8(math.Pow(clamp(1-d, 0, 1), 2) * 255)
+			img.SetRGBA(x, y, color.RGBA{a, a, a, a}) // premultiplied
+		}
+	}
+	return img
+}
+
+// bloomAlpha is how bright an explosion's glow is on a frame of its
+// animation, fading out as it goes
+func bloomAlpha(frame int) float64 {
+	return clamp(1-float64(frame-1)/7, 0, 1) * BloomIntensity
+}
+
+// drawBloom adds a soft glow to an explosion, sized to it and fading with it
+func drawBloom(screen *ebiten.Image, o *Explosion) {
+	if glowSprite == nil {
+		glowSprite = ebiten.NewImageFromImage(glowImage(glowSize))
+	}
+	scale := o.Radius * 2 * BloomSize / glowSize
+	op := &ebiten.DrawImageOptions{
+		CompositeMode: ebiten.CompositeModeLighter,
+		Filter:        ebiten.FilterLinear,
+	}
+	op.GeoM.Translate(-glowSize/2, -glowSize/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(float64(o.Center.X), float64(o.Center.Y))
+	a := bloomAlpha(o.Frame)
+	op.ColorM.Scale(a, a*0.8, a*0.5, a) // warm, like the explosion
+	screen.DrawImage(glowSprite, op)
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// calmFactor is how much of full difficulty a run is at, ticks into it, going
+// up evenly from CalmStart to 1 over the first CalmSeconds, or straight to 1
+// when there's no calm start
+func calmFactor(ticks int) float64 {
+	total := CalmSeconds * float64(ebiten.MaxTPS())
+	if total <= 0 || float64(ticks) >= total {
+		return 1
+	}
+	return CalmStart + (1-CalmStart)*float64(ticks)/total
+}
+
+// calmSpawnGap is how many ticks apart time attack asteroids come in, further
+// apart during the calm start
+func calmSpawnGap(ticks int) int {
+	return int(float64(timeAttackSpawnGap) / calmFactor(ticks))
+}
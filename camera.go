@@ -0,0 +1,79 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Camera is a debugging free camera for panning and zooming around the game
+// world, which draws to the screen at world*Zoom + (X, Y)
+type Camera struct {
+	Active bool
+	X, Y   float64
+	Zoom   float64
+	last   image.Point // screen cursor position while dragging
+	world  *ebiten.Image
+}
+
+// Update toggles the camera with C, then pans it while dragging with the
+// middle mouse button and zooms it towards the cursor with the scroll wheel
+func (c *Camera) Update() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		*c = Camera{Active: !c.Active, Zoom: 1, world: c.world}
+	}
+	if !c.Active {
+		return
+	}
+
+	cursor := image.Pt(ebiten.CursorPosition())
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+		if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonMiddle) {
+			d := cursor.Sub(c.last)
+			c.Pan(float64(d.X), float64(d.Y))
+		}
+		c.last = cursor
+	}
+	if _, wy := ebiten.Wheel(); wy != 0 {
+		c.ZoomAt(float64(cursor.X), float64(cursor.Y), math.Pow(1.1, wy))
+	}
+}
+
+// Pan moves the view by dx, dy screen pixels
+func (c *Camera) Pan(dx, dy float64) {
+	c.X += dx
+	c.Y += dy
+}
+
+// ZoomAt zooms in by factor, keeping whatever is at screen position x, y in
+// the same place on the screen
+func (c *Camera) ZoomAt(x, y, factor float64) {
+	wx, wy := c.ScreenToWorld(x, y)
+	c.Zoom = clamp(c.Zoom*factor, 0.25, 8)
+	c.X = x - wx*c.Zoom
+	c.Y = y - wy*c.Zoom
+}
+
+// GeoM is the camera's transform from the world to the screen
+func (c *Camera) GeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	if !c.Active {
+		return m
+	}
+	m.Scale(c.Zoom, c.Zoom)
+	m.Translate(c.X, c.Y)
+	return m
+}
+
+// ScreenToWorld finds where in the world screen position x, y is looking at
+func (c *Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	m := c.GeoM()
+	m.Invert()
+	return m.Apply(x, y)
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+// maxChainChance is the most the chain chance can go up to, short of 1 so
+// that every wave still comes to an end
+const maxChainChance = 0.9
+
+// chainChance is the chance that destroying an asteroid in a wave sends in a
+// replacement, going up from ChainChance by ChainPerWave each wave
+func chainChance(wave int) float64 {
+	if wave < 1 {
+		wave = 1
+	}
+	return clamp(ChainChance+ChainPerWave*float64(wave-1), 0, maxChainChance)
+}
+
+// chainSpawn might send in a replacement for a destroyed asteroid, and reports
+// whether it did
+func (g *Game) chainSpawn() bool {
+	chance := chainChance(g.Wave)
+	if chance <= 0 || !g.Spawning || g.Rand.Float64() >= chance {
+		return false
+	}
+	g.Spawn(1)
+	return true
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// An Action is something the player does with a key or mouse button
+type Action int
+
+// The actions that can be bound to keys and mouse buttons
+const (
+	ActionShoot Action = iota
+	ActionFocus
+	ActionPanic
+	ActionTractor
+	ActionAbilities
+	ActionLock
+)
+
+// Bindings are which keys and mouse buttons do each action, an action can
+// have either, both or neither
+type Bindings struct {
+	Keys    map[Action]ebiten.Key
+	Buttons map[Action]ebiten.MouseButton
+}
+
+// controlPresets are the sets of bindings the player can pick between
+var controlPresets = map[string]Bindings{
+	"right": {
+		Keys: map[Action]ebiten.Key{
+			ActionFocus:     ebiten.KeyShift,
+			ActionPanic:     ebiten.KeyB,
+			ActionTractor:   ebiten.KeyT,
+			ActionAbilities: ebiten.KeyQ,
+			ActionLock:      ebiten.KeyE,
+		},
+		Buttons: map[Action]ebiten.MouseButton{
+			ActionShoot: ebiten.MouseButtonLeft,
+			ActionFocus: ebiten.MouseButtonRight,
+		},
+	},
+	// The mouse is in the left hand, so its buttons swap, and the keys move
+	// over to the right hand side of the keyboard
+	"left": {
+		Keys: map[Action]ebiten.Key{
+			ActionFocus:     ebiten.KeyShift,
+			ActionPanic:     ebiten.KeyP,
+			ActionTractor:   ebiten.KeyO,
+			ActionAbilities: ebiten.KeyI,
+			ActionLock:      ebiten.KeyU,
+		},
+		Buttons: map[Action]ebiten.MouseButton{
+			ActionShoot: ebiten.MouseButtonRight,
+			ActionFocus: ebiten.MouseButtonLeft,
+		},
+	},
+}
+
+// controls are the bindings currently in use
+var controls = controlPresets["right"]
+
+// setControls switches to the preset called name, or right-handed if there's
+// no such preset
+func setControls(name string) {
+	b, ok := controlPresets[name]
+	if !ok {
+		b = controlPresets["right"]
+	}
+	controls = b
+}
+
+// keyName is what the key for an action is shown as in the HUD
+func keyName(a Action) string {
+	return strings.ToUpper(controls.Keys[a].String())
+}
+
+// Handed is the controls preset chosen on the title screen if there is one,
+// otherwise the one from the config
+func (s *SaveFile) Handed(fallback string) string {
+	if _, ok := controlPresets[s.Controls]; ok {
+		return s.Controls
+	}
+	return fallback
+}
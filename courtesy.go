@@ -0,0 +1,54 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// TimeRamp eases the game to a stop when the window loses focus and back up
+// to full speed when it comes back, so that alt-tabbing isn't a jarring freeze
+type TimeRamp struct {
+	Slowed float64 // how far towards stopped, from 0 for full speed to 1
+	carry  float64 // part of a tick built up while running below full speed
+}
+
+// Update moves a step towards stopped while unfocused and a step back towards
+// full speed while focused
+func (r *TimeRamp) Update(focused bool, step float64) {
+	if focused {
+		r.Slowed = clamp(r.Slowed-step, 0, 1)
+	} else {
+		r.Slowed = clamp(r.Slowed+step, 0, 1)
+	}
+}
+
+// Scale is how fast the game is running, from 0 for stopped to 1 for full speed
+func (r *TimeRamp) Scale() float64 {
+	return 1 - r.Slowed
+}
+
+// Advance reports whether the world should move on this tick, which at a
+// partial speed is only some of the time, going by how much time has built up
+func (r *TimeRamp) Advance() bool {
+	if r.Slowed == 0 {
+		r.carry = 0
+		return true
+	}
+	r.carry += r.Scale()
+	if r.carry < 1 {
+		return false
+	}
+	r.carry--
+	return true
+}
+
+// courtesyStep is how far the courtesy slow ramps each tick to take
+// CourtesySlowTime to stop or start, or all at once when that's zero
+func courtesyStep() float64 {
+	ticks := CourtesySlowTime * float64(ebiten.MaxTPS())
+	if ticks < 1 {
+		return 1
+	}
+	return 1 / ticks
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+)
+
+// dangerAlarmGap is the fewest ticks between danger alarms, so a crowd of
+// asteroids drifting in and out of range doesn't keep setting it off
+var dangerAlarmGap = 3 * ebiten.MaxTPS()
+
+// DangerWarning tracks when too many asteroids are close to the Earth at once
+type DangerWarning struct {
+	Active    bool
+	LastAlarm int // tick the alarm last sounded
+	alarmed   bool
+}
+
+// Update turns the warning on when close asteroids reach DangerCount and off
+// again when they drop below it, and reports whether to sound the alarm
+func (d *DangerWarning) Update(close, tick int) bool {
+	was := d.Active
+	d.Active = close >= DangerCount
+	if !d.Active || was {
+		return false
+	}
+	if d.alarmed && tick-d.LastAlarm < dangerAlarmGap {
+		return false
+	}
+	d.alarmed = true
+	d.LastAlarm = tick
+	return true
+}
+
+// Close counts the asteroids still coming that are within distance of the
+// Earth's surface
+func (as Asteroids) Close(distance float64) int {
+	n := 0
+	for _, v := range as {
+		if v.Alive && !v.Explosion.Exploding && v.Distance < distance {
+			n++
+		}
+	}
+	return n
+}
+
+// drawDanger flashes a warning across the middle of the screen
+func drawDanger(screen *ebiten.Image, g *Game) {
+	if g.Tick/(ebiten.MaxTPS()/4)%2 == 0 {
+		return
+	}
+	b, _ := font.BoundString(g.FontFace, "DANGER")
+	w := (b.Max.X - b.Min.X).Ceil() / 2
+	text.Draw(screen, "DANGER", g.FontFace, g.Width/2-w, g.Height/3, hud.Warning)
+}
+
+// dangerAlarm is a two-tone siren, made up rather than loaded like the other
+// sound effects
+func dangerAlarm(sampleRate int) []byte {
+	const seconds, tone = 0.6, 0.15
+	samples := int(seconds * float64(sampleRate))
+	buf := make([]byte, samples*4)
+	for i := 0; i < samples; i++ {
+		t := float64(i) / float64(sampleRate)
+		freq := 660.0
+		if int(t/tone)%2 == 1 {
+			freq = 440
+		}
+		fade := 1 - t/seconds
+		v := int16(math.Sin(2*math.Pi*freq*t) * fade * 0.4 * math.MaxInt16)
+		buf[i*4], buf[i*4+1] = byte(v), byte(v>>8)
+		buf[i*4+2], buf[i*4+3] = byte(v), byte(v>>8)
+	}
+	return buf
+}
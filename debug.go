@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"image"
 	"image/color"
 	"math"
 
@@ -13,7 +14,14 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
+// debugGridSize is the spacing of the grid the crosshair snaps to
+const debugGridSize = 16
+
 func debug(screen *ebiten.Image, g *Game) {
+	if SnapToGrid {
+		drawGrid(screen, g.Width, g.Height, debugGridSize)
+	}
+
 	ebitenutil.DrawRect(
 		screen,
 		float64(g.Width)/2-20,
@@ -41,7 +49,8 @@ func debug(screen *ebiten.Image, g *Game) {
 		color.RGBA{255, 0, 0, 255},
 	)
 
-	mx, my := ebiten.CursorPosition()
+	cursor := g.cursorPosition()
+	mx, my := cursor.X, cursor.Y
 	ebitenutil.DrawLine(
 		screen,
 		float64(g.Earth.Center.X),
@@ -57,6 +66,90 @@ func debug(screen *ebiten.Image, g *Game) {
 		mdx, mdy,
 		math.Sqrt(math.Pow(float64(mdx), 2)+math.Pow(float64(mdy), 2)),
 	))
+
+	if g.Camera.Active {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("CAMERA x%.2f", g.Camera.Zoom), 0, 16)
+	}
+}
+
+// debugAsteroids draws a ray from the Earth's centre along each asteroid's
+// angle, labelled with the angle, and a marker where its angle and distance
+// say it should be, which should sit right on top of the asteroid
+func debugAsteroids(screen *ebiten.Image, g *Game) {
+	ex, ey := g.Earth.Pt()
+	rayLength := math.Hypot(float64(g.Width), float64(g.Height))
+	for _, v := range g.Asteroids {
+		if !v.Alive {
+			continue
+		}
+		cos, sin := math.Cos(v.Angle), math.Sin(v.Angle)
+		ebitenutil.DrawLine(
+			screen,
+			ex, ey,
+			ex+rayLength*cos, ey+rayLength*sin,
+			color.RGBA{255, 0, 255, 255},
+		)
+
+		d := v.Distance + g.Earth.Radius
+		x, y := ex+d*cos, ey+d*sin
+		ebitenutil.DrawRect(screen, x-4, y-4, 8, 8, color.RGBA{255, 0, 255, 255})
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.2f", v.Angle), int(x)+8, int(y)+8)
+	}
+}
+
+// snapToGrid moves p to the nearest point on a grid with the given spacing
+func snapToGrid(p image.Point, size int) image.Point {
+	snap := func(v int) int {
+		return int(math.Round(float64(v)/float64(size))) * size
+	}
+	return image.Pt(snap(p.X), snap(p.Y))
+}
+
+// drawGrid faintly draws a grid with the given spacing over the screen
+func drawGrid(screen *ebiten.Image, width, height, size int) {
+	c := color.RGBA{255, 255, 255, 32}
+	for x := 0; x <= width; x += size {
+		ebitenutil.DrawLine(screen, float64(x), 0, float64(x), float64(height), c)
+	}
+	for y := 0; y <= height; y += size {
+		ebitenutil.DrawLine(screen, 0, float64(y), float64(width), float64(y), c)
+	}
+}
+
+// drawMoonCoverage draws faint lines from the moon to every asteroid in its
+// coverage, to show the area it's protecting
+func drawMoonCoverage(screen *ebiten.Image, g *Game) {
+	if g.Moon == nil {
+		return
+	}
+	mx, my := float64(g.Moon.Center.X), float64(g.Moon.Center.Y)
+	for _, v := range g.Asteroids {
+		if !v.Alive || v.Explosion.Exploding || !g.Moon.InCoverage(v) {
+			continue
+		}
+		ebitenutil.DrawLine(
+			screen,
+			mx, my,
+			float64(v.Center.X), float64(v.Center.Y),
+			color.RGBA{0, 255, 255, 64},
+		)
+	}
+}
+
+// drawMoonOrbit faintly draws the whole of the moon's orbit round the Earth, to
+// show where it's going to be
+func drawMoonOrbit(screen *ebiten.Image, g *Game) {
+	const segments = 90
+	if g.Moon == nil {
+		return
+	}
+	r := g.Moon.OrbitRadius(g)
+	cx, cy := float64(g.Width/2), float64(g.Height/2)
+	c := color.RGBA{255, 255, 255, 40}
+	for i := 0; i < segments; i++ {
+		a, b := 2*math.Pi*float64(i)/segments, 2*math.Pi*float64(i+1)/segments
+		ebitenutil.DrawLine(screen, cx+r*math.Cos(a), cy+r*math.Sin(a), cx+r*math.Cos(b), cy+r*math.Sin(b), c)
+	}
 }
 
 func fps(screen *ebiten.Image) {
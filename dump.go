@@ -0,0 +1,100 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"os"
+)
+
+// A FrameDump is everything in one frame of the game, written out as JSON for
+// outside tools to analyse or visualise
+type FrameDump struct {
+	State     SpectatorState // the same state spectators are sent
+	Asteroids []DumpObject
+	Earth     DumpObject
+	Moon      *DumpObject // nil without the moon
+	Crosshair DumpObject
+	Laser     *DumpLaser // nil when not shooting
+}
+
+// A DumpObject is where something is on screen and how fast it's moving there
+type DumpObject struct {
+	ID     int `json:",omitempty"`
+	X, Y   int
+	Radius float64
+	VX, VY float64 // pixels per tick
+}
+
+// A DumpLaser is the shot being fired this frame
+type DumpLaser struct {
+	FromX, FromY int
+	ToX, ToY     int
+}
+
+// dumpObject is where o is, standing still
+func dumpObject(o *Object) DumpObject {
+	return DumpObject{X: o.Center.X, Y: o.Center.Y, Radius: o.Radius}
+}
+
+// FrameDump is the game's current frame, built from its live state
+func (g *Game) FrameDump() FrameDump {
+	d := FrameDump{
+		State:     g.SpectatorState(),
+		Asteroids: make([]DumpObject, 0, len(g.Asteroids)),
+		Earth:     dumpObject(g.Earth.Object),
+		Crosshair: dumpObject(g.Crosshair.Object),
+	}
+	for _, v := range g.Asteroids {
+		o := dumpObject(v.Object)
+		o.ID = v.ID
+		if v.Alive && v.Captured == 0 && !v.Explosion.Exploding {
+			speed := v.Speed * calmFactor(g.Stats.Ticks)
+			o.VX, o.VY = -speed*math.Cos(v.Angle)+v.VX, -speed*math.Sin(v.Angle)+v.VY
+		}
+		d.Asteroids = append(d.Asteroids, o)
+	}
+	if g.Moon != nil {
+		m := dumpObject(g.Moon.Object)
+		d.Moon = &m
+	}
+	if g.Crosshair.Shooting {
+		d.Laser = &DumpLaser{
+			FromX: g.Crosshair.ShootingFrom.X,
+			FromY: g.Crosshair.ShootingFrom.Y,
+			ToX:   g.Crosshair.Center.X,
+			ToY:   g.Crosshair.Center.Y,
+		}
+	}
+	return d
+}
+
+// WriteFrameDump writes the game's current frame to w as a line of JSON
+func (g *Game) WriteFrameDump(w io.Writer) error {
+	return json.NewEncoder(w).Encode(g.FrameDump())
+}
+
+// dumpFrame adds the game's current frame to the end of DumpFile, or prints
+// it when that's empty, only logging if it goes wrong
+func (g *Game) dumpFrame() {
+	w := io.Writer(os.Stdout)
+	if DumpFile != "" {
+		f, err := os.OpenFile(DumpFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("error dumping frame: %v\n", err)
+			return
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := g.WriteFrameDump(w); err != nil {
+		log.Printf("error dumping frame: %v\n", err)
+		return
+	}
+	log.Printf("dumped frame at tick %d\n", g.Tick)
+}
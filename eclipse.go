@@ -0,0 +1,37 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Eclipsed reports whether the moon is passing between the Earth and the sun,
+// which shines from SunAngle degrees clockwise from the right of the screen
+func (g *Game) Eclipsed() bool {
+	if g.Moon == nil {
+		return false
+	}
+	r := g.Moon.OrbitRadius(g)
+	width := math.Asin(math.Min(g.Moon.Radius/r, 1)) // how wide the moon looks from the Earth
+	return angleBetween(g.Moon.Bearing(), SunAngle*math.Pi/180) <= width
+}
+
+// aidsHidden reports whether the eclipse is hiding the player's visual aids
+func (g *Game) aidsHidden() bool {
+	return EclipseBlind && g.Eclipsed()
+}
+
+// drawEclipse darkens the screen while the moon blocks out the sun
+func drawEclipse(screen *ebiten.Image, g *Game) {
+	if !Eclipses || !g.Eclipsed() {
+		return
+	}
+	ebitenutil.DrawRect(screen, 0, 0, float64(g.Width), float64(g.Height), color.RGBA{0, 0, 0, 0x70})
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "sync"
+
+// A soundEffect is anything that can be played from the start and cut off,
+// like an audio player
+type soundEffect interface {
+	Rewind() error
+	Play()
+	Pause()
+	IsPlaying() bool
+}
+
+// A mixer keeps track of the sound effects playing so that no more than
+// MaxSounds play at once, cutting off the oldest to make room for a new one
+type mixer struct {
+	mu     sync.Mutex // sounds are played from timer goroutines too
+	active []soundEffect
+}
+
+// effects is the mixer every sound effect goes through
+var effects mixer
+
+// play plays s from the start, first cutting off the oldest sounds still
+// playing if there are already MaxSounds
+func (m *mixer) play(s soundEffect) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Forget finished sounds, and s itself since it's starting over
+	active := m.active[:0]
+	for _, v := range m.active {
+		if v != s && v.IsPlaying() {
+			active = append(active, v)
+		}
+	}
+	for MaxSounds > 0 && len(active) >= MaxSounds {
+		active[0].Pause()
+		active = active[1:]
+	}
+
+	s.Rewind()
+	s.Play()
+	m.active = append(active, s)
+}
+
+// Playing is how many sound effects are playing
+func (m *mixer) Playing() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, v := range m.active {
+		if v.IsPlaying() {
+			n++
+		}
+	}
+	return n
+}
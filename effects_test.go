@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// fakeSound plays until it's paused
+type fakeSound struct {
+	playing bool
+	rewinds int
+}
+
+func (s *fakeSound) Rewind() error   { s.rewinds++; return nil }
+func (s *fakeSound) Play()           { s.playing = true }
+func (s *fakeSound) Pause()          { s.playing = false }
+func (s *fakeSound) IsPlaying() bool { return s.playing }
+
+func TestMixerCap(t *testing.T) {
+	defer func(n int) { MaxSounds = n }(MaxSounds)
+	MaxSounds = 3
+
+	var m mixer
+	sounds := make([]*fakeSound, 10)
+	for i := range sounds {
+		sounds[i] = &fakeSound{}
+		m.play(sounds[i])
+		if n := m.Playing(); n > MaxSounds {
+			t.Fatalf("%d sounds playing after %d played, cap is %d", n, i+1, MaxSounds)
+		}
+	}
+	if m.Playing() != 3 {
+		t.Errorf("%d sounds playing, want 3", m.Playing())
+	}
+	for i, s := range sounds {
+		if want := i >= 7; s.playing != want {
+			t.Errorf("sound %d playing %v, want %v, oldest should be cut off", i, s.playing, want)
+		}
+	}
+
+	// Playing a sound again restarts it without taking up another slot
+	m.play(sounds[8])
+	if m.Playing() != 3 || sounds[8].rewinds != 2 || !sounds[7].playing {
+		t.Errorf("replaying a sound cut off another one")
+	}
+
+	// Finished sounds make room
+	sounds[7].playing = false
+	m.play(sounds[0])
+	if !sounds[9].playing || !sounds[8].playing {
+		t.Errorf("a playing sound was cut off while a finished one made room")
+	}
+
+	MaxSounds = 0
+	for _, s := range sounds {
+		m.play(s)
+	}
+	if m.Playing() != len(sounds) {
+		t.Errorf("%d sounds playing with no cap, want all %d", m.Playing(), len(sounds))
+	}
+}
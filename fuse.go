@@ -0,0 +1,76 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+)
+
+// armFuses gives each of the asteroids a FuseChance of self-destructing
+// FuseSeconds after it comes on screen
+func (g *Game) armFuses(as Asteroids) {
+	if FuseChance <= 0 {
+		return
+	}
+	for _, v := range as {
+		if g.Rand.Float64() < FuseChance {
+			v.Fuse = int(FuseSeconds * float64(ebiten.MaxTPS()))
+		}
+	}
+}
+
+// Burst blows up an asteroid whose fuse ran out, taking a hit off every other
+// asteroid within BurstRadius of it, and hitting the Earth too if that's in
+// reach. It reports how many other asteroids it destroyed.
+func (g *Game) Burst(a *Asteroid) int {
+	a.Fuse = 0
+	if a.Distance <= BurstRadius {
+		a.Impacting = true // close enough to hurt the Earth
+	} else {
+		g.Count--
+	}
+	a.Explosion.Exploding = true
+	play(g.Sounds.ExplsnLo)
+
+	n := 0
+	for _, v := range g.Asteroids {
+		if v == a || !v.Alive || v.Explosion.Exploding {
+			continue
+		}
+		d := v.Center.Sub(a.Center)
+		if math.Hypot(float64(d.X), float64(d.Y)) > BurstRadius {
+			continue
+		}
+		v.Health--
+		if v.Health <= 0 {
+			v.Explosion.Exploding = true
+			g.Count--
+			g.chainSpawn()
+			n++
+		}
+	}
+	log.Printf("asteroid %d self-destructed, taking %d with it\n", a.ID, n)
+	return n
+}
+
+// drawFuses shows how many seconds are left on each lit fuse, next to its
+// asteroid
+func drawFuses(screen *ebiten.Image, g *Game) {
+	for _, v := range g.Asteroids {
+		if v.Fuse <= 0 || !v.Seen || !v.Alive || v.Explosion.Exploding {
+			continue
+		}
+		secs := fmt.Sprint((v.Fuse + ebiten.MaxTPS() - 1) / ebiten.MaxTPS())
+		b, _ := font.BoundString(g.FontFace, secs)
+		w := (b.Max.X - b.Min.X).Ceil() / 2
+		text.Draw(screen, secs, g.FontFace, v.Center.X-w, v.Center.Y, hud.Warning)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/font"
+)
+
+// A HelpPage is one page of the how to play panel
+type HelpPage struct {
+	Title string
+	Lines []string
+}
+
+// helpPages are the pages of the how to play panel, written for the current
+// settings and controls, add a page here to have it shown
+func helpPages() []HelpPage {
+	pages := []HelpPage{
+		{"HOW TO PLAY", []string{
+			"ASTEROIDS ARE FALLING TOWARDS THE EARTH",
+			"SHOOT THEM DOWN BEFORE ANY OF THEM HIT IT",
+			"MISSING COOLS YOUR LASER DOWN FOR A SECOND",
+			"THE MOON DESTROYS ANY ASTEROID IT TOUCHES",
+		}},
+		{"CONTROLS", []string{
+			"AIM WITH THE MOUSE, ARROW KEYS OR A GAMEPAD",
+			buttonName(controls.Buttons[ActionShoot]) + ": SHOOT",
+			"HOLD " + keyName(ActionFocus) + " OR " + buttonName(controls.Buttons[ActionFocus]) + ": AIM SLOWLY AND PRECISELY",
+			"F: FULLSCREEN  ESC: QUIT",
+		}},
+		{"SCORING", []string{
+			"A POINT FOR EVERY ASTEROID DESTROYED",
+			"BY YOU OR BY THE MOON",
+			"EVERY WAVE HAS MORE ASTEROIDS THAN THE LAST",
+			"SOME ASTEROIDS FLASH WHEN HIT, SHOOT THEM AGAIN",
+		}},
+	}
+
+	var abilities []string
+	if PanicButton {
+		abilities = append(abilities, keyName(ActionPanic)+": DESTROY EVERY ASTEROID NEAR THE EARTH")
+	}
+	if TractorBeam {
+		abilities = append(abilities, keyName(ActionTractor)+": HOLD THE CLOSEST ASTEROID IN PLACE")
+	}
+	if LockOn {
+		abilities = append(abilities, keyName(ActionLock)+": LOCK ON TO AN ASTEROID, SHOTS FOLLOW IT")
+	}
+	if RadialMenu {
+		abilities = append(abilities, "HOLD "+keyName(ActionAbilities)+": PICK AN ABILITY BY AIMING AT IT")
+	}
+	if Rewinds > 0 {
+		abilities = append(abilities, fmt.Sprintf("%d REWINDS: TIME GOES BACK INSTEAD OF A HIT", Rewinds))
+	}
+	if len(abilities) == 0 {
+		abilities = append(abilities, "NONE ARE TURNED ON, SEE THE CONFIG FILE")
+	} else {
+		abilities = append(abilities, "EACH ONE CAN BE USED ONCE A WAVE")
+	}
+	return append(pages, HelpPage{"ABILITIES", abilities})
+}
+
+// buttonName is what a mouse button is shown as in the HUD
+func buttonName(b ebiten.MouseButton) string {
+	switch b {
+	case ebiten.MouseButtonLeft:
+		return "LEFT CLICK"
+	case ebiten.MouseButtonRight:
+		return "RIGHT CLICK"
+	}
+	return "MIDDLE CLICK"
+}
+
+// turnHelpPage is the page after turning step pages on from page, out of n,
+// and false when that goes past the last page so the panel should close
+func turnHelpPage(page, step, n int) (int, bool) {
+	page += step
+	if page < 0 {
+		page = 0
+	}
+	return page, page < n
+}
+
+// updateHelp turns the how to play panel's pages with the arrow keys or a
+// click, closing it after the last one
+func (g *Game) updateHelp() {
+	step := 0
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		step = -1
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight), clicked():
+		step = 1
+	}
+	g.HelpPage, g.ShowHelp = turnHelpPage(g.HelpPage, step, len(helpPages()))
+}
+
+// drawHelp draws a page of the how to play panel
+func drawHelp(screen *ebiten.Image, page int, face font.Face, width, height int) {
+	pages := helpPages()
+	if page < 0 || page >= len(pages) {
+		return
+	}
+	p := pages[page]
+	lines := append([]string{}, p.Lines...)
+	lines = append(lines, "", fmt.Sprintf("< %d OF %d >", page+1, len(pages)))
+	drawPanel(screen, face, width, height, p.Title, lines)
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "image/color"
+
+// A Palette is the set of colours the HUD is drawn in
+type Palette struct {
+	Text    color.RGBA // all HUD and menu text
+	Warning color.RGBA // urgent messages, like the danger warning
+	Panel   color.RGBA // background behind the leaderboard and other panels
+}
+
+// hudThemes are the palettes the HUD can be drawn in, by the name used to pick
+// them in the config or on the title screen
+var hudThemes = map[string]Palette{
+	"white": {
+		Text:    color.RGBA{255, 255, 255, 255},
+		Warning: color.RGBA{255, 60, 60, 255},
+		Panel:   color.RGBA{0, 0, 0, 200},
+	},
+	"green": {
+		Text:    color.RGBA{80, 255, 120, 255},
+		Warning: color.RGBA{255, 200, 40, 255},
+		Panel:   color.RGBA{0, 20, 0, 200},
+	},
+	"amber": {
+		Text:    color.RGBA{255, 176, 0, 255},
+		Warning: color.RGBA{255, 60, 60, 255},
+		Panel:   color.RGBA{20, 10, 0, 200},
+	},
+	"blue": {
+		Text:    color.RGBA{110, 200, 255, 255},
+		Warning: color.RGBA{255, 140, 40, 255},
+		Panel:   color.RGBA{0, 0, 24, 200},
+	},
+}
+
+// hudThemeNames are the HUD themes in the order the title screen cycles them
+var hudThemeNames = []string{"white", "green", "amber", "blue"}
+
+// hud is the palette the HUD is currently drawn in
+var hud = hudThemes["white"]
+
+// setHudTheme switches the HUD to the theme called name, or white if there's
+// no such theme
+func setHudTheme(name string) {
+	p, ok := hudThemes[name]
+	if !ok {
+		p = hudThemes["white"]
+	}
+	hud = p
+}
+
+// nextHudTheme is the theme after name when cycling through them
+func nextHudTheme(name string) string {
+	for i, n := range hudThemeNames {
+		if n == name {
+			return hudThemeNames[(i+1)%len(hudThemeNames)]
+		}
+	}
+	return hudThemeNames[0]
+}
+
+// HudTheme is the theme chosen on the title screen if there is one, otherwise
+// the one from the config
+func (s *SaveFile) HudTheme(fallback string) string {
+	if _, ok := hudThemes[s.Theme]; ok {
+		return s.Theme
+	}
+	return fallback
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "math"
+
+// Nearest is how far the closest asteroid still coming is from the Earth's
+// surface, or infinity if there isn't one
+func (as Asteroids) Nearest() float64 {
+	nearest := math.Inf(1)
+	for _, v := range as {
+		if v.Alive && !v.Explosion.Exploding {
+			nearest = math.Min(nearest, v.Distance)
+		}
+	}
+	return nearest
+}
+
+// incomingVolume is how loud the incoming hum should be with the closest
+// asteroid distance from the Earth, silent beyond IncomingRange and rising to
+// IncomingVolume right at the surface
+func incomingVolume(distance float64) float64 {
+	if IncomingRange <= 0 {
+		return 0
+	}
+	return IncomingVolume * clamp(1-distance/IncomingRange, 0, 1)
+}
+
+// UpdateIncoming fades the incoming hum towards how loud it should be with the
+// closest asteroid distance from the Earth
+func (s *Sounds) UpdateIncoming(distance float64) {
+	const fade = 0.05
+	if s.Incoming == nil {
+		return
+	}
+	v := s.Incoming.Volume()
+	s.Incoming.SetVolume(v + (incomingVolume(distance)*Volume-v)*fade)
+}
+
+// incomingHum is a second of low rumble, two deep tones beating against each
+// other, as 16-bit stereo PCM that loops without a click
+func incomingHum(sampleRate int) []byte {
+	buf := make([]byte, sampleRate*4)
+	for i := 0; i < sampleRate; i++ {
+		t := float64(i) / float64(sampleRate)
+		// Two close whole-number frequencies beat slowly against each other
+		// and both fit exactly into the second
+		w := math.Sin(2*math.Pi*41*t) + math.Sin(2*math.Pi*43*t)
+		v := int16(w / 2 * 0.5 * math.MaxInt16)
+		buf[i*4], buf[i*4+1] = byte(v), byte(v>>8)
+		buf[i*4+2], buf[i*4+3] = byte(v), byte(v>>8)
+	}
+	return buf
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+)
+
+// LeaderboardSize is how many scores are kept on the leaderboard
+const LeaderboardSize = 10
+
+// A ScoreEntry is a single line on the leaderboard
+type ScoreEntry struct {
+	Initials string
+	Score    int
+	Wave     int
+	Time     time.Time
+}
+
+// The Leaderboard is the list of best scores, highest first
+type Leaderboard []ScoreEntry
+
+// Qualifies reports whether a score is good enough to get on the leaderboard
+func (l Leaderboard) Qualifies(score int) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(l) < LeaderboardSize || score > l[len(l)-1].Score
+}
+
+// Best is the top score on the leaderboard, 0 if it's empty
+func (l Leaderboard) Best() int {
+	if len(l) == 0 {
+		return 0
+	}
+	return l[0].Score
+}
+
+// Add puts an entry in its place on the leaderboard, dropping whoever falls off
+// the bottom, and reports whether the entry made it on
+func (l *Leaderboard) Add(e ScoreEntry) bool {
+	if !l.Qualifies(e.Score) {
+		return false
+	}
+	// Earlier entries win ties, so a new entry goes after equal scores
+	i := sort.Search(len(*l), func(i int) bool { return (*l)[i].Score < e.Score })
+	*l = append(*l, ScoreEntry{})
+	copy((*l)[i+1:], (*l)[i:])
+	(*l)[i] = e
+	if len(*l) > LeaderboardSize {
+		*l = (*l)[:LeaderboardSize]
+	}
+	return true
+}
+
+// Draw renders the leaderboard centred on a dark panel
+func (l Leaderboard) Draw(screen *ebiten.Image, face font.Face, width, height int) {
+	lines := make([]string, 0, LeaderboardSize)
+	if len(l) == 0 {
+		lines = append(lines, "NO SCORES YET")
+	}
+	for i, e := range l {
+		lines = append(lines, fmt.Sprintf("%2d. %-3s %5d  W%-3d %s",
+			i+1, e.Initials, e.Score, e.Wave, e.Time.Format("2006-01-02"),
+		))
+	}
+	drawPanel(screen, face, width, height, "HIGH SCORES", lines)
+}
+
+// readInitials adds letters typed this tick to initials, up to three, and
+// handles backspace, reporting whether enter was pressed to finish
+func readInitials(initials string) (string, bool) {
+	for _, r := range ebiten.InputChars() {
+		if len(initials) < 3 && r < unicode.MaxASCII && unicode.IsLetter(r) {
+			initials += strings.ToUpper(string(r))
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(initials) > 0 {
+		initials = initials[:len(initials)-1]
+	}
+	done := len(initials) > 0 && inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+	return initials, done
+}
+
+// drawPanel draws a title and lines of text centred on a dark panel across
+// the middle of the screen
+func drawPanel(screen *ebiten.Image, face font.Face, width, height int, title string, lines []string) {
+	f, _ := font.BoundString(face, "0")
+	lineH := (f.Max.Y - f.Min.Y).Ceil() * 2
+	panelH := lineH * (len(lines) + 2)
+	top := height/2 - panelH/2
+	ebitenutil.DrawRect(
+		screen,
+		0, float64(top),
+		float64(width), float64(panelH),
+		hud.Panel,
+	)
+
+	drawTextCentred(screen, title, face, width/2, top+lineH)
+	for i, line := range lines {
+		drawTextCentred(screen, line, face, width/2, top+lineH*(i+2)+lineH/2)
+	}
+}
+
+// drawTextCentred draws str horizontally centred on x with its baseline at y
+func drawTextCentred(screen *ebiten.Image, str string, face font.Face, x, y int) {
+	b, _ := font.BoundString(face, str)
+	w := (b.Max.X - b.Min.X).Ceil() / 2
+	text.Draw(screen, str, face, x-w, y, hud.Text)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardAdd(t *testing.T) {
+	var l Leaderboard
+	for _, score := range []int{5, 20, 10, 20, 1} {
+		if !l.Add(ScoreEntry{Score: score}) {
+			t.Errorf("score %d didn't make it on a short leaderboard", score)
+		}
+	}
+	want := []int{20, 20, 10, 5, 1}
+	for i, e := range l {
+		if e.Score != want[i] {
+			t.Fatalf("leaderboard %v, want scores %v", l, want)
+		}
+	}
+
+	if l.Add(ScoreEntry{Score: 0}) {
+		t.Errorf("a zero score made it on the leaderboard")
+	}
+}
+
+func TestLeaderboardTiesKeepOrder(t *testing.T) {
+	var l Leaderboard
+	l.Add(ScoreEntry{Initials: "AAA", Score: 5})
+	l.Add(ScoreEntry{Initials: "BBB", Score: 5})
+	if l[0].Initials != "AAA" || l[1].Initials != "BBB" {
+		t.Errorf("tied scores reordered: %v", l)
+	}
+}
+
+func TestLeaderboardCap(t *testing.T) {
+	var l Leaderboard
+	for i := 1; i <= LeaderboardSize+5; i++ {
+		l.Add(ScoreEntry{Score: i})
+	}
+	if len(l) != LeaderboardSize {
+		t.Fatalf("leaderboard has %d entries, want %d", len(l), LeaderboardSize)
+	}
+	if l[0].Score != LeaderboardSize+5 || l[len(l)-1].Score != 6 {
+		t.Errorf("wrong scores kept: %v", l)
+	}
+
+	if l.Qualifies(6) || l.Add(ScoreEntry{Score: 6}) {
+		t.Errorf("score equal to the lowest on a full leaderboard made it on")
+	}
+	if !l.Add(ScoreEntry{Score: 7}) || len(l) != LeaderboardSize || l[len(l)-1].Score != 7 {
+		t.Errorf("adding to a full leaderboard gave %v", l)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.save")
+
+	save, err := LoadSave(path)
+	if err != nil || len(save.Leaderboard) != 0 {
+		t.Fatalf("missing save file gave %v, %v", save, err)
+	}
+
+	when := time.Date(2020, 12, 1, 12, 0, 0, 0, time.UTC)
+	save.Leaderboard.Add(ScoreEntry{Initials: "SLR", Score: 42, Wave: 3, Time: when})
+	if err := save.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSave(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Leaderboard) != 1 {
+		t.Fatalf("loaded leaderboard %v", loaded.Leaderboard)
+	}
+	e := loaded.Leaderboard[0]
+	if e.Initials != "SLR" || e.Score != 42 || e.Wave != 3 || !e.Time.Equal(when) {
+		t.Errorf("loaded entry %v", e)
+	}
+}
+
+func TestRecordScreenshot(t *testing.T) {
+	defer func(auto bool, name string) { AutoScreenshot, SaveFileName = auto, name }(AutoScreenshot, SaveFileName)
+	SaveFileName = ""
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Save.Leaderboard = Leaderboard{{Score: 10}, {Score: 3}}
+
+	for _, tt := range []struct {
+		auto  bool
+		score int
+		want  bool
+	}{
+		{true, 11, true},
+		{true, 10, false},
+		{true, 5, false},
+		{false, 11, false},
+	} {
+		AutoScreenshot = tt.auto
+		g.Score = tt.score
+		g.Record = false
+		g.EndGame()
+		if g.Record != tt.want {
+			t.Errorf("auto %v, score %d against best 10: screenshot %v, want %v", tt.auto, tt.score, g.Record, tt.want)
+		}
+	}
+
+	// The screenshot waits for the leaderboard with the new record on it
+	now := time.Now()
+	g.Clock = func() time.Time { return now }
+	AutoScreenshot = true
+	g.Score = 11
+	g.EndGame()
+	if g.recordShowing() {
+		t.Errorf("record showing straight away at game over")
+	}
+	now = now.Add(time.Hour)
+	if g.recordShowing() {
+		t.Errorf("record showing while the initials are still being typed in")
+	}
+	g.NewScore, g.ShowScores = false, true
+	if !g.recordShowing() {
+		t.Errorf("record not showing once it's on the leaderboard")
+	}
+
+	if got := screenshotPath(11, time.Now()); got != "" {
+		t.Errorf("screenshot saved to %q without a save file", got)
+	}
+	SaveFileName = filepath.Join("saves", "lunar-defence.save")
+	at := time.Date(2020, 12, 25, 9, 30, 0, 0, time.UTC)
+	if got, want := screenshotPath(11, at), filepath.Join("saves", "lunar-defence-11-20201225-093000.png"); got != want {
+		t.Errorf("screenshot saved to %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// An ImageLoader loads the game's images by name, like "assets/earth.png"
+type ImageLoader interface {
+	Load(name string) (*ebiten.Image, error)
+}
+
+// assetLoader loads images from the assets embedded in the game
+type assetLoader struct{}
+
+// Load decodes the named PNG from the embedded assets
+func (assetLoader) Load(name string) (*ebiten.Image, error) {
+	log.Printf("loading %s\n", name)
+
+	file, err := assets.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %v", name, err)
+	}
+	defer file.Close()
+
+	return decodePNG(name, file)
+}
+
+// decodePNG decodes the PNG called name, failing for one with no size just as
+// png.Decode does
+func decodePNG(name string, r io.Reader) (*ebiten.Image, error) {
+	raw, err := png.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding file %s as PNG: %v", name, err)
+	}
+
+	return ebiten.NewImageFromImage(raw), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// memoryLoader makes square images, sized by name, instead of loading them
+type memoryLoader map[string]int
+
+func (l memoryLoader) Load(name string) (*ebiten.Image, error) {
+	size, ok := l[name]
+	if !ok {
+		return nil, fmt.Errorf("no image %s", name)
+	}
+	img := ebiten.NewImage(size, size)
+	img.Fill(color.White)
+	return img, nil
+}
+
+func TestMemoryLoaderGame(t *testing.T) {
+	loader := memoryLoader{
+		"assets/earth.png":     64,
+		"assets/explosion.png": 16,
+		"assets/crosshair.png": 16,
+		"assets/moon.png":      32,
+		"assets/turret.png":    8,
+		"assets/gameover.png":  64,
+		"assets/asteroid.png":  12,
+	}
+	g, err := NewGame(640, 480, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 5
+	g.Restart()
+	if len(g.Asteroids) != 5 {
+		t.Fatalf("%d asteroids, want 5", len(g.Asteroids))
+	}
+	for _, v := range g.Asteroids {
+		if v.Radius != 6 || v.Explosion.Radius != 8 {
+			t.Errorf("asteroid radius %v explosion %v, want 6 and 8", v.Radius, v.Explosion.Radius)
+		}
+	}
+}
+
+func TestAssetLoaderMissing(t *testing.T) {
+	if _, err := (assetLoader{}).Load("assets/missing.png"); err == nil {
+		t.Errorf("loaded an asset that doesn't exist")
+	}
+}
+
+// emptyLoader gives images with no size
+type emptyLoader struct{}
+
+func (emptyLoader) Load(name string) (*ebiten.Image, error) {
+	return ebiten.NewImage(4, 4).SubImage(image.Rectangle{}).(*ebiten.Image), nil
+}
+
+func TestBadImages(t *testing.T) {
+	if _, err := decodePNG("garbage", strings.NewReader("not a png")); err == nil {
+		t.Errorf("decoded garbage as a PNG")
+	}
+
+	// A real PNG, changed to say it's zero pixels wide
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint32(b[16:], 0)                            // IHDR width
+	binary.BigEndian.PutUint32(b[29:], crc32.ChecksumIEEE(b[12:29])) // IHDR checksum
+	if _, err := decodePNG("zero", bytes.NewReader(b)); err == nil {
+		t.Errorf("decoded a zero width PNG")
+	}
+
+	if _, err := NewGame(640, 480, emptyLoader{}); err == nil || !strings.Contains(err.Error(), "has no size") {
+		t.Errorf("made a game out of images with no size: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// lockPressed is shorthand for when the lock key, E unless rebound, has just
+// been pressed
+func lockPressed() bool {
+	return inpututil.IsKeyJustPressed(controls.Keys[ActionLock])
+}
+
+// Lock locks onto the asteroid under the crosshair, so that shots follow it
+// wherever it goes, and returns it, or lets go of the lock and returns nil if
+// there isn't one there
+func (g *Game) Lock() *Asteroid {
+	targets := g.Crosshair.Targets(g.Asteroids, false, g.Tick)
+	if len(targets) == 0 {
+		g.Locked = 0
+		return nil
+	}
+	g.Locked = targets[0].ID
+	log.Printf("locked on to asteroid %d\n", g.Locked)
+	return targets[0]
+}
+
+// LockedTarget is the locked asteroid while it's still there to be shot, or
+// nil, letting go of the lock once it's destroyed
+func (g *Game) LockedTarget() *Asteroid {
+	if g.Locked == 0 {
+		return nil
+	}
+	for _, v := range g.Asteroids {
+		if v.ID == g.Locked && v.Alive && !v.Explosion.Exploding {
+			return v
+		}
+	}
+	g.Locked = 0
+	return nil
+}
+
+// drawLock draws brackets round the corners of the locked asteroid
+func drawLock(screen *ebiten.Image, g *Game) {
+	const arm = 8
+	if g.Locked == 0 {
+		return
+	}
+	for _, v := range g.Asteroids {
+		if v.ID != g.Locked || !v.Alive || v.Explosion.Exploding {
+			continue
+		}
+		r := v.Radius + 4
+		x, y := float64(v.Center.X), float64(v.Center.Y)
+		for _, c := range [][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			cx, cy := x+c[0]*r, y+c[1]*r
+			ebitenutil.DrawLine(screen, cx, cy, cx-c[0]*arm, cy, hud.Warning)
+			ebitenutil.DrawLine(screen, cx, cy, cx, cy-c[1]*arm, hud.Warning)
+		}
+	}
+}
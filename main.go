@@ -8,18 +8,25 @@ import (
 	"bytes"
 	"embed"
 	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
+	"io"
 	"log"
 	"math"
 	"math/rand"
+	"net"
+	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
@@ -36,104 +43,280 @@ var (
 	WaveMultiplier     int     = 2
 	RotationSpeed      float64 = 0.02
 	MoonOrbitRatio     float64 = 2
+	MoonOrbitSpeed     float64 = 0.01
 	MoonOrbitDistance  float64 = 5
 	AsteroidSpinRatio  float64 = 3
+	AimMode            string  = "absolute"
+	CrosshairSpeed     float64 = 10
+	Brightness         float64 = 1
+	ShaderPreset       string  = "none"
+	FocusSensitivity   float64 = 0.3
+	ReducedMotion      bool    = false
+	Debug              bool    = false
+	GameOverDelay      float64 = 1
+	Volume             float64 = 1
+	NoMoon             bool    = false
+	MaxRotationSpeed   float64 = 0.1
+	MaxSpinSpeed       float64 = 0.3
+	Practice           bool    = false
+	Filter             string  = "nearest"
+	BehindTheMoon      bool    = false
+	BehindMoonSpread   float64 = 0.5
+	Rewinds            int     = 0
+	WaveHeal           bool    = false
+	WaveTints          string  = "ffffff fff6ee ffeedd ffe6cc ffddbb ffd5aa"
+	SnapToGrid         bool    = false
+	DangerCount        int     = 5
+	DangerDistance     float64 = 150
+	Easy               bool    = false
+	EasyMoonReach      float64 = 2
+	ShowCoverage       bool    = false
+	ShowOrbit          bool    = false
+	TimeAttack         bool    = false
+	TimeAttackSeconds  float64 = 60
+	Bloom              bool    = true
+	BloomSize          float64 = 1.5
+	BloomIntensity     float64 = 0.5
+	GravityAssist      bool    = false
+	GravityReach       float64 = 3
+	GravityStrength    float64 = 0.01
+	MoonGravity        bool    = false
+	MoonPull           float64 = 0.05
+	CrosshairColour    string  = "white"
+	HudTheme           string  = "white"
+	Controls           string  = "right"
+	PanicButton        bool    = true
+	PanicRadius        float64 = 300
+	RandomRotation     bool    = true
+	BeltEvery          int     = 0
+	BeltSize           int     = 3
+	BeltSpeed          float64 = 0.4
+	RunModifiers       bool    = false
+	CourtesySlow       bool    = false
+	CourtesySlowTime   float64 = 0.5
+	MinVisibleTime     float64 = 2
+	TractorBeam        bool    = false
+	TractorSeconds     float64 = 3
+	MultiHit           bool    = true
+	IncomingVolume     float64 = 0.3
+	IncomingRange      float64 = 400
+	RadialMenu         bool    = false
+	MaxSounds          int     = 6
+	StudyMode          bool    = false
+	AsteroidHealth     int     = 1
+	HealthEvery        int     = 0
+	MaxHealth          int     = 3
+	Level              string  = ""
+	ChainChance        float64 = 0
+	ChainPerWave       float64 = 0
+	FuseChance         float64 = 0
+	FuseSeconds        float64 = 5
+	BurstRadius        float64 = 150
+	ScoreBase          float64 = 1
+	ScorePerWave       float64 = 0
+	ScoreDistance      float64 = 0
+	ScoreFused         float64 = 0
+	AutoScreenshot     bool    = false
+	CalmSeconds        float64 = 0
+	CalmStart          float64 = 0.5
+	TurretArc          bool    = false
+	ArcDegrees         float64 = 180
+	DumpFile           string  = ""
+	LockOn             bool    = false
+	Eclipses           bool    = true
+	EclipseBlind       bool    = false
+	SunAngle           float64 = 0
+	NearMiss           string  = "off"
+	NearMissMargin     float64 = 20
+	NearMissBoost      float64 = 1.5
+	Atmosphere         float64 = 80
+	BurnRate           float64 = 0
+	BurnScore          int     = 0
+	AsteroidSpeed      float64 = 1
+	StudyFile          string  = "lunar-defence-study.log"
 )
 
-//go:embed assets/*.png assets/*.ogg
+//go:embed assets/*.png assets/*.ogg assets/*.kage assets/levels/*.json
 var assets embed.FS
 
 func main() {
-	ebiten.SetWindowSize(640, 480)
+	benchmark := flag.Bool("benchmark", false, "run the benchmark wave without a window and print timings")
+	broadcast := flag.String("broadcast", "", "let spectators watch from this address, like :7777")
+	spectate := flag.String("spectate", "", "watch the game being broadcast from this address, like localhost:7777")
+	flag.Parse()
+	if *benchmark {
+		applyConfigs()
+		runBenchmark(os.Stdout)
+		return
+	}
+
+	gameWidth, gameHeight := 1280, 960
+	ebiten.SetWindowSize(windowSize(gameWidth, gameHeight, 640, 480, ebiten.DeviceScaleFactor()))
 	ebiten.SetWindowTitle("Lunar Defence")
 	ebiten.SetCursorMode(ebiten.CursorModeHidden)
 
 	applyConfigs()
+	if Debug && StudyMode {
+		study, err := startStudy(StudyFile)
+		if err != nil {
+			log.Fatalf("error starting study mode: %v\n", err)
+		}
+		defer study.Close()
+	}
 
-	gameWidth, gameHeight := 1280, 960
-	rand.Seed(time.Now().UnixNano())
-	howMany := HowManyStart // starting number of asteroids
-	fontFace := loadFont()
-
-	game := &Game{
-		Width:      gameWidth,
-		Height:     gameHeight,
-		FontFace:   fontFace,
-		Loading:    true,
-		GameOver:   false,
-		Breathless: false,
-		Rotation:   0,
-		Count:      0,
-		Wave:       0,
-		HowMany:    howMany,
-		Moon:       nil,
-		Earth:      nil,
-		Asteroids:  nil,
-		Crosshair:  nil,
-		GOText:     nil,
-		Entities:   nil,
-		Sounds:     nil,
-	}
-
-	go NewGame(game)
+	game, err := NewGame(gameWidth, gameHeight, assetLoader{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *broadcast != "" {
+		l, err := net.Listen("tcp", *broadcast)
+		if err != nil {
+			log.Fatalf("error starting broadcast: %v\n", err)
+		}
+		game.Broadcast = Broadcast(l)
+	}
+	if *spectate != "" {
+		conn, err := net.Dial("tcp", *spectate)
+		if err != nil {
+			log.Fatalf("error connecting to broadcast: %v\n", err)
+		}
+		game.Spectator = Spectate(conn)
+		game.Sounds = &Sounds{}                              // silent
+		game.State = NewStateMachine(StatePlaying)           // no title or restarting
+		game.Entities = game.Entities[:len(game.Entities)-1] // no crosshair to draw
+	}
 
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
 }
 
-// NewGame sets up a new game object with default states and game objects
-func NewGame(game *Game) {
+// NewGame sets up a new game with default states and game objects, loading
+// its images with loader
+func NewGame(width, height int, loader ImageLoader, opts ...Option) (*Game, error) {
+	images := map[string]*ebiten.Image{}
+	for _, name := range []string{"earth", "explosion", "crosshair", "moon", "turret", "gameover", "asteroid"} {
+		img, err := loader.Load("assets/" + name + ".png")
+		if err != nil {
+			return nil, err
+		}
+		if img.Bounds().Empty() { // nothing made from it would have a Radius
+			return nil, fmt.Errorf("image %s has no size", name)
+		}
+		images[name] = img
+	}
+
+	game := &Game{
+		Width:    width,
+		Height:   height,
+		FontFace: loadFont(),
+		HowMany:  HowManyStart, // starting number of asteroids
+		Images:   images,
+		Spawning: true,
+	}
+	for _, opt := range opts {
+		opt(game)
+	}
+	if game.Rand == nil {
+		game.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if Level != "" {
+		schedule, err := LoadSchedule(Level)
+		if err != nil {
+			log.Printf("error loading level %s: %v\n", Level, err)
+		}
+		game.Schedule = schedule
+	}
+	game.Base = currentTunables()
+	game.NewRun()
+
 	earth := &Earth{
-		Object:   NewObject(("assets/earth.png")),
-		Center:   image.Point{game.Width / 2, game.Height / 2},
-		Impacted: false,
+		Object:      NewObjectFromImage(images["earth"]),
+		Center:      image.Point{game.Width / 2, game.Height / 2},
+		Impacted:    false,
+		WobbleTicks: -1,
 	}
 	game.Earth = earth
 
 	explosion := &Explosion{
-		Object:    NewObjectFromImage(loadImage("assets/explosion.png")),
+		Object:    NewObjectFromImage(images["explosion"]),
 		Frame:     1,
 		Exploding: false,
 		Done:      false,
 	}
 	explosion.Radius = float64(explosion.Image.Bounds().Dy() / 2)
 	game.Crosshair = &Crosshair{
-		Object:    NewObject(("assets/crosshair.png")),
+		Object:    NewObjectFromImage(images["crosshair"]),
 		Explosion: explosion,
+		X:         float64(game.Width / 2),
+		Y:         float64(game.Height / 2),
+		Reticles:  newReticles(images["crosshair"]),
 	}
 
-	game.Moon = &Moon{
-		Object: NewObject("assets/moon.png"),
-		Turret: &Turret{
-			Object: NewObject("assets/turret.png"),
-			Angle:  0,
-		},
+	if !NoMoon {
+		game.Moon = &Moon{
+			Object: NewObjectFromImage(images["moon"]),
+			Turret: &Turret{
+				Object: NewObjectFromImage(images["turret"]),
+				Angle:  0,
+			},
+		}
 	}
 
-	gotext := NewObject("assets/gameover.png")
+	gotext := NewObjectFromImage(images["gameover"])
 	gotext.Op.GeoM.Translate(
 		float64(game.Width/2-gotext.Image.Bounds().Dx()/2),
 		float64(game.Height/2-gotext.Image.Bounds().Dy()/2),
 	)
 	game.GOText = gotext
 
-	entities := []Entity{
-		Asteroids{},
-		game.Moon,
-		game.Earth,
-		game.Crosshair,
+	game.Shader = loadShader(ShaderPreset)
+
+	save, err := LoadSave(SaveFileName)
+	if err != nil {
+		log.Printf("error loading save file: %v\n", err)
+	}
+	game.Save = save
+	game.Crosshair.Colour = save.CrosshairColour(CrosshairColour)
+	setHudTheme(save.HudTheme(HudTheme))
+	setControls(save.Handed(Controls))
+	if p, ok := profileNamed(save.ChosenProfile()); ok {
+		game.applyProfile(p)
+	}
+
+	entities := []Entity{Asteroids{}}
+	if game.Moon != nil {
+		entities = append(entities, game.Moon)
 	}
+	entities = append(entities, game.Earth, game.Crosshair)
 	game.Entities = entities
+	game.State = game.newStates()
+
+	return game, nil
+}
 
-	game.Loading = false
+// An Option changes how NewGame sets up the game
+type Option func(*Game)
+
+// WithRand makes the game take all of its randomness from r, so that it can
+// be controlled in tests
+func WithRand(r *rand.Rand) Option {
+	return func(g *Game) {
+		g.Rand = r
+	}
+}
+
+// lastAsteroidID is the ID given to the most recently made asteroid
+var lastAsteroidID int64
+
+// nextAsteroidID is a new asteroid ID, never given out before
+func nextAsteroidID() int {
+	return int(atomic.AddInt64(&lastAsteroidID, 1))
 }
 
-// NewAsteroids makes a fresh set of asteroids
-func NewAsteroids(earthRadius float64, howMany int) Asteroids {
+// NewAsteroids makes a fresh set of asteroids, placed randomly by r
+func NewAsteroids(asteroidImage, explosionImage *ebiten.Image, r *rand.Rand, earthRadius float64, howMany int) Asteroids {
 	asteroids := make(Asteroids, 0, howMany)
-	asteroidImage := loadImage("assets/asteroid.png")
-	explosionImage := loadImage("assets/explosion.png")
 	for i := 0; i < howMany; i++ {
 		explosion := &Explosion{
 			Object:    NewObjectFromImage(explosionImage),
@@ -144,14 +327,17 @@ func NewAsteroids(earthRadius float64, howMany int) Asteroids {
 		explosion.Radius = float64(explosion.Image.Bounds().Dy() / 2)
 
 		edgeOfScreenOffset := earthRadius * EdgeOfScreenOffset
-		distance := rand.Float64() * earthRadius * float64(howMany) / DistanceVariance
+		distance := r.Float64() * earthRadius * float64(howMany) / DistanceVariance
 		asteroids = append(asteroids, &Asteroid{
 			Object:    NewObjectFromImage(asteroidImage),
-			Angle:     rand.Float64() * math.Pi * 2,
+			Angle:     r.Float64() * math.Pi * 2,
 			Distance:  edgeOfScreenOffset + distance,
 			Explosion: explosion,
 			Alive:     true,
 			Impacting: false,
+			Speed:     AsteroidSpeed,
+			ID:        nextAsteroidID(),
+			Health:    1,
 		})
 	}
 
@@ -169,7 +355,6 @@ type Entity interface {
 type Game struct {
 	Width      int
 	Height     int
-	Loading    bool
 	FontFace   font.Face
 	Rotation   float64
 	Count      int
@@ -184,6 +369,51 @@ type Game struct {
 	GOText     *Object
 	Entities   []Entity
 	Sounds     *Sounds
+	Frame      *ebiten.Image  // offscreen frame for post-processing
+	Shader     *ebiten.Shader // post-processing shader, nil for none
+	Score      int
+	Tick       int // how many updates since the game loaded
+	Practice   PracticeStats
+	Save       *SaveFile
+	Initials   string // initials being typed in for a new high score
+	NewScore   bool   // when a game over score is going on the leaderboard
+	ShowScores bool   // when the leaderboard is showing
+	ShowGoals  bool   // when the achievements are showing
+	Stats      RunStats
+	Toast      string     // briefly shown message, like an unlocked achievement
+	ToastTicks int        // how much longer to show the toast for
+	History    []Snapshot // recent ticks, to rewind to
+	Rewinds    int        // how many rewinds are left this run
+	SlowMo     int        // ticks of slow motion left
+	Camera     Camera     // debugging free camera
+	Danger     DangerWarning
+	Rand       *rand.Rand               // source of all the game's randomness
+	Clock      func() time.Time         // tells the time, time.Now if nil
+	RestUntil  time.Time                // game over takes no input until then
+	BreathEnds time.Time                // the next wave comes in then, zero for no wait
+	Images     map[string]*ebiten.Image // loaded by NewGame, by name
+	TimeLeft   int                      // ticks left in a time attack run
+	Spawning   bool                     // whether new asteroids can come in
+	PanicReady bool                     // the panic button can still be used this wave
+	Base       Tunables                 // settings from before any modifiers
+	Modifiers  []Modifier               // chosen for this run
+	Offer      []Modifier               // to choose from between waves
+	Choosing   OfferMenu                // which modifier on offer is picked
+	Flash      int                      // ticks left of the panic button flash
+	State      *StateMachine            // title, playing or game over
+	Courtesy   TimeRamp                 // slows down when the window loses focus
+	TractorOK  bool                     // the tractor beam can still be used this wave
+	Radial     AbilityMenu              // quick menu of abilities
+	Broadcast  *Broadcaster             // sends the game to spectators, nil for none
+	Spectator  *Spectator               // follows a broadcast game instead of playing
+	Schedule   *Schedule                // hand-made level, nil for random waves
+	LevelTick  int                      // ticks since the level's wave started
+	ShowHelp   bool                     // when the how to play panel is showing
+	HelpPage   int                      // which page of it
+	Record     bool                     // screenshot the new record once it's showing
+	Locked     int                      // ID of the asteroid shots follow, 0 for none
+	ShowPicker bool                     // when the difficulty profiles are showing
+	Preview    ProfilePreview           // the difficulty profile being looked at
 }
 
 // Update calculates game logic
@@ -194,7 +424,7 @@ func (g *Game) Update() error {
 		return errors.New("game quit by player")
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) && !g.NewScore {
 		if ebiten.IsFullscreen() {
 			ebiten.SetFullscreen(false)
 		} else {
@@ -202,13 +432,35 @@ func (g *Game) Update() error {
 		}
 	}
 
-	// Skip updating while the game is loading
-	if g.Loading {
+	if g.Spectator != nil {
+		g.updateSpectating()
 		return nil
 	}
 
-	// Impact logic
-	if g.Asteroids.Alive() && g.Asteroids.Impacting() {
+	g.Tick++
+	if CourtesySlow {
+		g.Courtesy.Update(ebiten.IsFocused(), courtesyStep())
+	}
+	running := g.Courtesy.Advance() // false when this tick is slowed away
+	if TimeAttack && running {
+		g.updateTimeAttack()
+	}
+	if g.Schedule != nil && running {
+		g.updateSchedule()
+	}
+
+	// Impact logic, rewinding time instead if there are rewinds left
+	if g.Asteroids.Alive() && g.Asteroids.Impacting() && !g.Earth.Impacted && g.Rewind() {
+		log.Printf("rewound, %d rewinds left\n", g.Rewinds)
+	} else if g.Asteroids.Alive() && g.Asteroids.Impacting() {
+		if !g.Earth.Impacted {
+			for _, v := range g.Asteroids {
+				if v.Impacting {
+					g.Earth.Wobble(v.Angle)
+					break
+				}
+			}
+		}
 		g.Earth.Impacted = true
 	}
 
@@ -219,138 +471,625 @@ func (g *Game) Update() error {
 				v.Explosion.Exploding = true
 			}
 		} else if !g.GameOver {
-			g.GameOver = true
-			log.Println("game over")
-			g.Sounds.ExplsnLo.Rewind()
-			g.Sounds.ExplsnLo.Play()
-			g.Breathless = true
-			takeABreath := time.NewTimer(time.Second)
-			go func() {
-				log.Println("waiting")
-				<-takeABreath.C
-				g.Breathless = false
-			}()
+			g.gameOver()
 		}
 	}
 
 	// Next wave
-	if !g.GameOver && !g.Asteroids.Alive() && !g.Breathless && g.Wave > 0 {
+	if !g.GameOver && !g.Asteroids.Alive() && !g.Pending() && !g.Breathless && g.Wave > 0 && !TimeAttack {
 		log.Println("wave passed")
 		g.Wave++
+		g.Stats.Waves++
+		g.recordStreak()
+		if WaveHeal && g.Heal() {
+			log.Printf("healed, %d rewinds left\n", g.Rewinds)
+		}
+		if RunModifiers {
+			g.offerModifiers()
+		}
 		g.Breathless = true
-		takeABreath := time.NewTimer(time.Second * time.Duration(TimeBetweenWaves))
-		go func() {
-			log.Println("waiting")
-			<-takeABreath.C
-			g.HowMany *= WaveMultiplier
-			g.Restart()
-			g.Breathless = false // needs to come after restart
-		}()
+		if len(g.Offer) == 0 {
+			g.takeABreath()
+		}
+	}
+
+	if g.Sounds != nil {
+		g.Sounds.UpdateMusic(g.ThreatLevel())
+		g.Sounds.UpdateIncoming(g.Asteroids.Nearest())
+	}
+	if len(g.Offer) > 0 {
+		g.updateOffer()
+		if len(g.Offer) == 0 {
+			g.takeABreath() // the next wave waits for a modifier to be chosen
+		}
+	}
+	g.updateBreath()
+	if PanicButton && g.Wave > 0 && !g.GameOver && !g.Earth.Impacted && panicPressed() {
+		g.Panic()
+	}
+	if TractorBeam && g.Wave > 0 && !g.GameOver && !g.Earth.Impacted && tractorPressed() {
+		g.Tractor()
+	}
+	if LockOn && g.Wave > 0 && !g.GameOver && !g.Earth.Impacted && lockPressed() {
+		g.Lock()
+	}
+	g.LockedTarget() // lets go of the lock once the target's destroyed
+	if RadialMenu && g.Wave > 0 && !g.GameOver && !g.Earth.Impacted {
+		g.updateRadial()
+	} else {
+		g.Radial.Open = false
+	}
+	if g.Flash > 0 {
+		g.Flash--
+	}
+	if g.Danger.Update(g.Asteroids.Close(DangerDistance), g.Tick) && g.Sounds != nil {
+		play(g.Sounds.Danger)
+	}
+
+	if g.Wave > 0 && !g.GameOver {
+		g.Stats.Ticks++
+	}
+	g.unlockAchievements()
+	if g.ToastTicks > 0 {
+		g.ToastTicks--
+	}
+
+	if Debug {
+		g.Camera.Update()
+		if inpututil.IsKeyJustPressed(ebiten.KeyJ) {
+			g.dumpFrame()
+		}
+	}
+
+	// In slow motion everything but the crosshair only moves every other tick,
+	// and less and less often while easing to a stop
+	slow := g.SlowMo > 0 && g.SlowMo%2 == 0 || !running
+	if g.Radial.Open {
+		slow = slow || g.Tick%4 != 0 // a quarter speed while picking an ability
+	}
+	if g.SlowMo > 0 {
+		g.SlowMo--
 	}
 
 	// Global rotation for orbiting bodies
-	g.Rotation = g.Rotation - RotationSpeed
+	if !slow {
+		g.Rotation = g.Rotation - capSpeed(RotationSpeed, MaxRotationSpeed)
+	}
 
 	// Update object positions
 	for _, v := range g.Entities {
+		if slow && v != Entity(g.Crosshair) {
+			continue
+		}
 		v.Update(g)
 	}
+	if g.Wave > 0 && !g.GameOver && !g.Earth.Impacted {
+		g.recordHistory()
+	}
+
+	g.State.Update()
+
+	if g.Broadcast != nil {
+		g.Broadcast.Send(g.SpectatorState())
+	}
+
+	return nil
+}
+
+// Gunpoint is where lasers are shot from, the Moon's turret or the Earth when
+// there's no Moon
+func (g *Game) Gunpoint() image.Point {
+	if g.Moon == nil {
+		return g.Earth.Center
+	}
+	return g.Moon.Center
+}
 
-	// On wave zero, click to start the game
-	if g.Wave == 0 && clicked() {
+// newStates sets up the screens the game moves between
+func (g *Game) newStates() *StateMachine {
+	m := NewStateMachine(StateTitle)
+	m.Add(StateTitle, StateHooks{Update: g.updateTitle, Draw: g.drawTitle}, StatePlaying)
+	m.Add(StatePlaying, StateHooks{Enter: g.startPlaying}, StateGameOver)
+	m.Add(StateGameOver, StateHooks{Enter: g.EndGame, Update: g.updateGameOver}, StatePlaying)
+	return m
+}
+
+// updateTitle lets the player press L to look at the leaderboard, A for
+// achievements, F1 for how to play, D to pick a difficulty, H to change the
+// HUD colours, M to mirror the controls for left-handed players or click to
+// start the game
+func (g *Game) updateTitle() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF1) {
+		g.ShowHelp = !g.ShowHelp
+		g.HelpPage = 0
+		g.ShowScores = false
+		g.ShowGoals = false
+	} else if g.ShowHelp {
+		g.updateHelp()
+		return // clicking turns the page instead of starting
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		g.ShowPicker = !g.ShowPicker
+		g.ShowScores = false
+		g.ShowGoals = false
+		g.Preview.Reset(g, g.Preview.Showing)
+	} else if g.ShowPicker {
+		g.updateProfiles()
+		return // clicking chooses the profile instead of starting
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		g.ShowScores = !g.ShowScores
+		g.ShowGoals = false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		g.ShowGoals = !g.ShowGoals
+		g.ShowScores = false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+		g.Save.Theme = nextHudTheme(g.Save.HudTheme(HudTheme))
+		setHudTheme(g.Save.Theme)
+		if err := g.Save.Write(SaveFileName); err != nil {
+			log.Printf("error writing save file: %v\n", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.Save.Controls = "left"
+		if g.Save.Handed(Controls) == "left" {
+			g.Save.Controls = "right"
+		}
+		setControls(g.Save.Controls)
+		if err := g.Save.Write(SaveFileName); err != nil {
+			log.Printf("error writing save file: %v\n", err)
+		}
+	}
+	if clicked() {
+		if err := g.State.Transition(StatePlaying); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// startPlaying begins a new run, from the title or after a game over
+func (g *Game) startPlaying() {
+	if g.Wave == 0 {
 		g.Wave++
+	}
+	g.NewRun()
+	g.ShowScores = false
+	g.ShowGoals = false
+	g.ShowHelp = false
+	g.ShowPicker = false
+	if g.Sounds == nil {
 		g.Sounds = NewSounds()
-		g.Restart()
 	}
+	g.Restart()
+}
 
-	// Game restart
-	if g.GameOver && clicked() && !g.Breathless {
-		g.Restart()
+// gameOver ends the run by moving to the game over state
+func (g *Game) gameOver() {
+	if err := g.State.Transition(StateGameOver); err != nil {
+		log.Println(err)
 	}
+}
 
-	return nil
+// updateGameOver has the player type in initials for a new high score, then
+// shows where it landed, and restarts on a click once that's done
+func (g *Game) updateGameOver() {
+	if g.NewScore && !g.Resting() {
+		var done bool
+		g.Initials, done = readInitials(g.Initials)
+		if done {
+			g.Save.Leaderboard.Add(ScoreEntry{
+				Initials: g.Initials,
+				Score:    g.Score,
+				Wave:     g.Wave,
+				Time:     time.Now(),
+			})
+			if err := g.Save.Write(SaveFileName); err != nil {
+				log.Printf("error writing save file: %v\n", err)
+			}
+			g.NewScore = false
+			g.ShowScores = true
+		}
+	}
+	if g.CanRestart() && clicked() {
+		if err := g.State.Transition(StatePlaying); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// EndGame switches to the game over state, holding off any input for a moment
+// so the player doesn't skip past it by accident
+func (g *Game) EndGame() {
+	g.GameOver = true
+	g.Spawning = false
+	log.Println("game over")
+	g.NewScore = g.Save.Leaderboard.Qualifies(g.Score)
+	g.Record = AutoScreenshot && g.Score > g.bestScore()
+	if TimeAttack {
+		g.NewScore = false // time attack keeps its own best score
+		g.recordTimeAttack()
+	}
+	g.Initials = ""
+	play(g.Sounds.ExplsnLo)
+	g.RestUntil = g.now().Add(time.Duration(GameOverDelay * float64(time.Second)))
+}
+
+// Resting reports whether the game over screen is still holding off input
+func (g *Game) Resting() bool {
+	return g.GameOver && g.now().Before(g.RestUntil)
+}
+
+// now is the time by the game's clock
+func (g *Game) now() time.Time {
+	if g.Clock == nil {
+		return time.Now()
+	}
+	return g.Clock()
+}
+
+// unlockAchievements saves any new achievements and pops up a toast for them
+func (g *Game) unlockAchievements() {
+	unlocked := g.Save.UnlockAchievements(g.Stats)
+	if len(unlocked) == 0 {
+		return
+	}
+	for _, a := range unlocked {
+		log.Printf("achievement unlocked: %s\n", a.Name)
+	}
+	g.Toast = "ACHIEVEMENT: " + unlocked[len(unlocked)-1].Name
+	g.ToastTicks = 3 * ebiten.MaxTPS()
+	if err := g.Save.Write(SaveFileName); err != nil {
+		log.Printf("error writing save file: %v\n", err)
+	}
+}
+
+// CanRestart reports whether the game is over and ready to be restarted
+func (g *Game) CanRestart() bool {
+	return g.GameOver && !g.Resting() && !g.NewScore
+}
+
+// ThreatLevel is how much danger the Earth is in, from 0 for none up to 1, going
+// up with the number of living asteroids and how close they are
+func (g *Game) ThreatLevel() float64 {
+	const maxThreat = 3 // this many asteroids about to hit is as bad as it gets
+	danger := g.Earth.Radius * EdgeOfScreenOffset
+	threat := 0.0
+	for _, v := range g.Asteroids {
+		if v.Alive && !v.Explosion.Exploding {
+			threat += clamp(1-v.Distance/danger, 0, 1)
+		}
+	}
+	return clamp(threat/maxThreat, 0, 1)
+}
+
+// NewRun resets everything that's counted over a single run of the game, from
+// starting until game over
+func (g *Game) NewRun() {
+	g.Base.restore()
+	g.Modifiers = nil
+	g.Offer = nil
+	g.BreathEnds = time.Time{}
+	g.Score = 0
+	g.Stats = RunStats{}
+	g.Rewinds = Rewinds
+	g.TimeLeft = timeAttackTicks()
+	if RandomRotation {
+		g.Rotation = g.Rand.Float64() * -2 * math.Pi
+	}
+	if g.Moon != nil {
+		g.Moon.OrbitAngle = g.Rotation / MoonOrbitRatio // lined up like it always has been
+	}
+}
+
+// takeABreath gives the player TimeBetweenWaves seconds before the next wave
+func (g *Game) takeABreath() {
+	log.Println("waiting")
+	g.BreathEnds = g.now().Add(time.Second * time.Duration(TimeBetweenWaves))
+}
+
+// updateBreath sends in the next wave once the breath between waves is over
+func (g *Game) updateBreath() {
+	if g.BreathEnds.IsZero() || g.now().Before(g.BreathEnds) {
+		return
+	}
+	g.BreathEnds = time.Time{}
+	if g.nextWave() {
+		g.Breathless = false // needs to come after restart
+	}
+}
+
+// nextWave grows the number of asteroids and sends them in, unless spawning
+// has stopped, like when the game ended while waiting for the wave
+func (g *Game) nextWave() bool {
+	if !g.Spawning {
+		return false
+	}
+	if !Practice {
+		g.HowMany *= WaveMultiplier
+	}
+	g.Restart()
+	return true
+}
+
+// Spawn sends in howMany more asteroids on top of the ones already coming
+func (g *Game) Spawn(howMany int) {
+	more := NewAsteroids(g.Images["asteroid"], g.Images["explosion"], g.Rand, g.Earth.Radius, howMany)
+	more.SetHealth(asteroidHealth(g.Wave))
+	g.sendIn(more)
+	g.Count += len(more)
+}
+
+// sendIn readies new asteroids for the wave, slowing, arming and tinting them,
+// and adds them to the ones already coming
+func (g *Game) sendIn(more Asteroids) {
+	more.LimitSpeed()
+	g.armFuses(more)
+	tintAsteroids(more, g.Wave)
+	g.Asteroids = append(g.Asteroids, more...)
+	g.Entities[0] = g.Asteroids
 }
 
 // Restart starts a new game with states reset
 func (g *Game) Restart() {
 	log.Printf("new wave: %d\n", g.HowMany)
-	g.Count = g.HowMany
-	g.Asteroids = NewAsteroids(g.Earth.Radius, g.HowMany)
+	howMany := g.HowMany
+	if g.BeltWave() {
+		howMany *= BeltSize
+	}
+	if g.Schedule != nil {
+		howMany = 0 // the level sends them in when they're due
+	}
+	g.Count = howMany
+	if g.Rand == nil {
+		g.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	g.Asteroids = NewAsteroids(g.Images["asteroid"], g.Images["explosion"], g.Rand, g.Earth.Radius, howMany)
+	if Practice {
+		for _, v := range g.Asteroids {
+			v.Angle = practiceAngle
+			v.Distance = float64(g.Height / 2) // just off the top of the screen
+		}
+	}
+	if BehindTheMoon && g.Moon != nil {
+		bearing := g.Moon.Bearing()
+		for _, v := range g.Asteroids {
+			v.Angle = bearing + (g.Rand.Float64()*2-1)*BehindMoonSpread
+		}
+	}
+	if g.BeltWave() {
+		formBelt(g.Asteroids, g.Rand, g.Earth.Radius*EdgeOfScreenOffset)
+	}
+	if g.Schedule != nil {
+		g.Count = len(g.Schedule.Wave(g.Wave))
+		g.LevelTick = 0
+	}
+	g.Asteroids.LimitSpeed()
+	g.Asteroids.SetHealth(asteroidHealth(g.Wave))
+	g.armFuses(g.Asteroids)
+	tintAsteroids(g.Asteroids, g.Wave)
 	g.Entities[0] = g.Asteroids
+	g.History = g.History[:0]
 	g.Earth.Impacted = false
 	g.GameOver = false
+	g.Spawning = true
+	g.PanicReady = PanicButton
+	g.TractorOK = TractorBeam
+	g.Locked = 0
 }
 
-// Draw handles rendering the sprites
+// Draw handles rendering the sprites, via an offscreen frame when it needs
+// post-processing before it reaches the screen
 func (g *Game) Draw(screen *ebiten.Image) {
+	if g.Record && g.recordShowing() {
+		g.Record = false
+		defer saveScreenshot(screen, screenshotPath(g.Score, time.Now()))
+	}
 
-	if g.Loading {
-		loadText := "LOADING..."
-		loadTextF, _ := font.BoundString(g.FontFace, loadText)
-		loadTextW := (loadTextF.Max.X - loadTextF.Min.X).Ceil() / 2
-		loadTextH := (loadTextF.Max.Y - loadTextF.Min.Y).Ceil() / 2
-		text.Draw(screen, loadText, g.FontFace, g.Width/2-loadTextW, g.Height/2-loadTextH, color.White)
+	if Brightness == 1 && g.Shader == nil {
+		g.drawFrame(screen)
 		return
 	}
-	if !g.Loading && g.Wave == 0 {
-		startText := "CLICK TO START"
-		startTextF, _ := font.BoundString(g.FontFace, startText)
-		startTextW := (startTextF.Max.X - startTextF.Min.X).Ceil() / 2
-		startTextH := (startTextF.Max.Y - startTextF.Min.Y).Ceil() * 2
-		text.Draw(screen, startText, g.FontFace, g.Width/2-startTextW, startTextH, color.White)
-		creditsText := "By: Siôn le Roux www.sinisterstuf.org"
-		creditsTextF, _ := font.BoundString(g.FontFace, creditsText)
-		creditsTextW := (creditsTextF.Max.X - creditsTextF.Min.X).Ceil() / 2
-		creditsTextH := (creditsTextF.Max.Y - creditsTextF.Min.Y).Ceil() * 2
-		text.Draw(screen, creditsText, g.FontFace, g.Width/2-creditsTextW, g.Height-creditsTextH*2, color.White)
-		musicText := "Music: The Water & the Well - Nihilore"
-		musicTextF, _ := font.BoundString(g.FontFace, musicText)
-		musicTextW := (musicTextF.Max.X - musicTextF.Min.X).Ceil() / 2
-		musicTextH := (musicTextF.Max.Y - musicTextF.Min.Y).Ceil() * 2
-		text.Draw(screen, musicText, g.FontFace, g.Width/2-musicTextW, g.Height-musicTextH, color.White)
-		titleText := "Lunar Defence"
-		titleTextF, _ := font.BoundString(g.FontFace, titleText)
-		titleTextW := (titleTextF.Max.X - titleTextF.Min.X).Ceil() / 2
-		titleTextH := (titleTextF.Max.Y - titleTextF.Min.Y).Ceil() * 2
-		text.Draw(screen, titleText, g.FontFace, g.Width/2-titleTextW, g.Height-titleTextH*4, color.White)
-	}
-
-	// Draw game objects
+
+	if g.Frame == nil {
+		g.Frame = ebiten.NewImage(g.Width, g.Height)
+	}
+	g.Frame.Clear()
+	g.drawFrame(g.Frame)
+
+	if g.Shader != nil {
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images[0] = g.Frame
+		op.Uniforms = map[string]interface{}{
+			"Brightness": float32(Brightness),
+			"ScreenSize": []float32{float32(g.Width), float32(g.Height)},
+		}
+		screen.DrawRectShader(g.Width, g.Height, g.Shader, op)
+		return
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(Brightness, Brightness, Brightness, 1)
+	screen.DrawImage(g.Frame, op)
+}
+
+// drawWorld renders the game objects, through the free camera when it's on
+func (g *Game) drawWorld(screen *ebiten.Image) {
+	world := screen
+	if g.Camera.Active {
+		if g.Camera.world == nil {
+			g.Camera.world = ebiten.NewImage(g.Width, g.Height)
+		}
+		world = g.Camera.world
+		world.Clear()
+	}
+
+	hidden := g.aidsHidden()
+	if Debug || ShowOrbit && !hidden {
+		drawMoonOrbit(world, g)
+	}
+	drawTractorBeam(world, g)
+	drawTurretArc(world, g)
+	if !hidden {
+		drawLock(world, g)
+	}
+
 	for _, v := range g.Entities {
-		v.Draw(screen)
+		v.Draw(world)
+	}
+	if !hidden {
+		drawFuses(world, g)
+	}
+	if Debug || ShowCoverage && !hidden {
+		drawMoonCoverage(world, g)
+	}
+	if Debug {
+		debugAsteroids(world, g)
 	}
 
-	if g.GameOver {
+	if g.Camera.Active {
+		op := &ebiten.DrawImageOptions{GeoM: g.Camera.GeoM()}
+		screen.DrawImage(world, op)
+	}
+}
+
+// cursorPosition is where the cursor is pointing in the game world, which is
+// only different from the screen position under the free camera
+func (g *Game) cursorPosition() image.Point {
+	cx, cy := ebiten.CursorPosition()
+	x, y := g.Camera.ScreenToWorld(float64(cx), float64(cy))
+	return image.Pt(int(math.Round(x)), int(math.Round(y)))
+}
+
+// drawTitle shows the title screen text, underneath the world
+func (g *Game) drawTitle(screen *ebiten.Image) {
+	startText := "CLICK TO START"
+	startTextF, _ := font.BoundString(g.FontFace, startText)
+	startTextW := (startTextF.Max.X - startTextF.Min.X).Ceil() / 2
+	startTextH := (startTextF.Max.Y - startTextF.Min.Y).Ceil() * 2
+	text.Draw(screen, startText, g.FontFace, g.Width/2-startTextW, startTextH, hud.Text)
+	creditsText := "By: Siôn le Roux www.sinisterstuf.org"
+	creditsTextF, _ := font.BoundString(g.FontFace, creditsText)
+	creditsTextW := (creditsTextF.Max.X - creditsTextF.Min.X).Ceil() / 2
+	creditsTextH := (creditsTextF.Max.Y - creditsTextF.Min.Y).Ceil() * 2
+	text.Draw(screen, creditsText, g.FontFace, g.Width/2-creditsTextW, g.Height-creditsTextH*2, hud.Text)
+	musicText := "Music: The Water & the Well - Nihilore"
+	musicTextF, _ := font.BoundString(g.FontFace, musicText)
+	musicTextW := (musicTextF.Max.X - musicTextF.Min.X).Ceil() / 2
+	musicTextH := (musicTextF.Max.Y - musicTextF.Min.Y).Ceil() * 2
+	text.Draw(screen, musicText, g.FontFace, g.Width/2-musicTextW, g.Height-musicTextH, hud.Text)
+	titleText := "Lunar Defence"
+	titleTextF, _ := font.BoundString(g.FontFace, titleText)
+	titleTextW := (titleTextF.Max.X - titleTextF.Min.X).Ceil() / 2
+	titleTextH := (titleTextF.Max.Y - titleTextF.Min.Y).Ceil() * 2
+	text.Draw(screen, titleText, g.FontFace, g.Width/2-titleTextW, g.Height-titleTextH*4, hud.Text)
+	drawTextCentred(screen, "L: HIGH SCORES  A: ACHIEVEMENTS  H: HUD COLOUR  D: DIFFICULTY", g.FontFace, g.Width/2, g.Height-titleTextH*3)
+	drawTextCentred(screen, g.Save.StreakProgress(), g.FontFace, g.Width/2, g.Height-titleTextH*5)
+	handed := "F1: HOW TO PLAY  M: LEFT-HANDED CONTROLS"
+	if g.Save.Handed(Controls) == "left" {
+		handed = "F1: HOW TO PLAY  M: RIGHT-HANDED CONTROLS"
+	}
+	drawTextCentred(screen, handed, g.FontFace, g.Width/2, g.Height-titleTextH*6)
+	drawTestPattern(screen, g.Width/2, startTextH*2)
+}
+
+// drawFrame renders everything in the game onto a single frame
+func (g *Game) drawFrame(screen *ebiten.Image) {
+
+	g.State.Draw(screen)
+
+	g.drawWorld(screen)
+	drawEclipse(screen, g)
+
+	if g.GameOver && !g.ShowScores {
 		screen.DrawImage(g.GOText.Image, g.GOText.Op)
 	}
+	if g.ShowScores {
+		g.Save.Leaderboard.Draw(screen, g.FontFace, g.Width, g.Height)
+	}
+	if g.ShowGoals {
+		drawAchievements(screen, g.Save, g.FontFace, g.Width, g.Height)
+	}
+	if g.ShowHelp {
+		drawHelp(screen, g.HelpPage, g.FontFace, g.Width, g.Height)
+	}
+	if g.ShowPicker {
+		drawProfiles(screen, g)
+	}
+	if len(g.Offer) > 0 {
+		drawModifiers(screen, g.Offer, g.Choosing.Choice, g.FontFace, g.Width, g.Height)
+	}
 
 	// HUD and other text
 	padding := 20
 	f, _ := font.BoundString(g.FontFace, "00")
 	h := (f.Max.Y - f.Min.Y).Ceil() * 2
 	w := (f.Max.X - f.Min.X).Ceil() + padding
-	text.Draw(screen, strconv.Itoa(g.Count), g.FontFace, padding, h, color.White)
-	text.Draw(screen, strconv.Itoa(g.Wave), g.FontFace, g.Width-w, h, color.White)
+	text.Draw(screen, strconv.Itoa(g.Count), g.FontFace, padding, h, hud.Text)
+	text.Draw(screen, strconv.Itoa(g.Wave), g.FontFace, g.Width-w, h, hud.Text)
+	if g.Wave > 0 {
+		text.Draw(screen, strconv.Itoa(g.Score), g.FontFace, padding, g.Height-padding, hud.Text)
+	}
+	if g.Wave > 0 && !g.GameOver {
+		var ready []string
+		if g.PanicReady {
+			ready = append(ready, keyName(ActionPanic)+": PANIC")
+		}
+		if g.TractorOK {
+			ready = append(ready, keyName(ActionTractor)+": TRACTOR")
+		}
+		drawTextCentred(screen, strings.Join(ready, "  "), g.FontFace, g.Width/2, g.Height-padding)
+	}
+	if g.Radial.Open {
+		drawRadial(screen, g)
+	}
+	if g.Flash > 0 {
+		drawPanicFlash(screen, g)
+	}
+	if g.Wave > 0 && Rewinds > 0 {
+		rewinds := fmt.Sprintf("REWINDS %d", g.Rewinds)
+		rewindsF, _ := font.BoundString(g.FontFace, rewinds)
+		rewindsW := (rewindsF.Max.X - rewindsF.Min.X).Ceil() + padding
+		text.Draw(screen, rewinds, g.FontFace, g.Width-rewindsW, g.Height-padding, hud.Text)
+	}
+	if g.Danger.Active && !g.GameOver && !g.aidsHidden() {
+		drawDanger(screen, g)
+	}
+	if g.ToastTicks > 0 {
+		drawTextCentred(screen, g.Toast, g.FontFace, g.Width/2, h*2)
+	}
+	if g.Wave > 0 && Practice {
+		g.Practice.Draw(screen, g.FontFace, g.Width, g.Height-h)
+	}
+	if g.Wave > 0 && TimeAttack {
+		drawTimeAttack(screen, g, g.FontFace)
+	}
 	if g.Crosshair.CoolingDown && !g.Breathless { // TODO: this should be in Crosshair.Draw()
 		missText := "MISSED: COOLING DOWN!"
 		missTextF, _ := font.BoundString(g.FontFace, missText)
 		missTextW := (missTextF.Max.X - missTextF.Min.X).Ceil() / 2
-		text.Draw(screen, missText, g.FontFace, g.Width/2-missTextW, h, color.White)
+		text.Draw(screen, missText, g.FontFace, g.Width/2-missTextW, h, hud.Text)
 	}
 	if !g.GameOver && g.Breathless {
 		tryAgain := fmt.Sprintf("WAVE %d", g.Wave)
 		tryAgainF, _ := font.BoundString(g.FontFace, tryAgain)
 		tryAgainW := (tryAgainF.Max.X - tryAgainF.Min.X).Ceil() / 2
-		text.Draw(screen, tryAgain, g.FontFace, g.Width/2-tryAgainW, h, color.White)
+		text.Draw(screen, tryAgain, g.FontFace, g.Width/2-tryAgainW, h, hud.Text)
 	}
-	if g.GameOver && !g.Breathless {
+	if g.GameOver && !g.Resting() && g.NewScore {
+		drawTextCentred(screen, "NEW HIGH SCORE! TYPE YOUR INITIALS", g.FontFace, g.Width/2, h)
+		drawTextCentred(screen, fmt.Sprintf("%-3s", g.Initials+"_"), g.FontFace, g.Width/2, h*2)
+	} else if g.GameOver && !g.Resting() {
 		tryAgain := "CLICK TO TRY AGAIN"
 		tryAgainF, _ := font.BoundString(g.FontFace, tryAgain)
 		tryAgainW := (tryAgainF.Max.X - tryAgainF.Min.X).Ceil() / 2
-		text.Draw(screen, tryAgain, g.FontFace, g.Width/2-tryAgainW, h, color.White)
+		text.Draw(screen, tryAgain, g.FontFace, g.Width/2-tryAgainW, h, hud.Text)
 	}
 
-	// debug(screen, g)
+	if Debug {
+		debug(screen, g)
+	}
 }
 
 // Layout is hardcoded for now, may be made dynamic in future
@@ -358,19 +1097,168 @@ func (g *Game) Layout(outsideWidth int, outsideHeight int) (screenWidth int, scr
 	return g.Width, g.Height
 }
 
+// windowSize picks a window size no bigger than maxWidth by maxHeight, in
+// device-independent pixels, where each physical pixel shows a whole number of
+// game pixels so it stays crisp at any device scale, e.g. on a 2x display
+// the game maps exactly one game pixel to each physical pixel.
+func windowSize(gameWidth, gameHeight, maxWidth, maxHeight int, scale float64) (int, int) {
+	if scale <= 0 {
+		scale = 1
+	}
+	for k := 1; ; k++ {
+		w := float64(gameWidth) / float64(k) / scale
+		h := float64(gameHeight) / float64(k) / scale
+		if w <= float64(maxWidth) && h <= float64(maxHeight) {
+			return int(math.Round(w)), int(math.Round(h))
+		}
+	}
+}
+
 func applyConfigs() {
 	cfg, err := ini.Load("lunar-defence.ini")
 	log.Println(err)
 	if err == nil {
-		HowManyStart, _ = cfg.Section("").Key("HowManyStart").Int()
+		HowManyStart = cfg.Section("").Key("HowManyStart").MustInt(HowManyStart)
+		if HowManyStart < 1 {
+			log.Printf("HowManyStart must be at least 1, not %d\n", HowManyStart)
+			HowManyStart = 1
+		}
 		EdgeOfScreenOffset, _ = cfg.Section("").Key("EdgeOfScreenOffset").Float64()
 		DistanceVariance, _ = cfg.Section("").Key("DistanceVariance").Float64()
 		TimeBetweenWaves, _ = cfg.Section("").Key("TimeBetweenWaves").Int()
 		WaveMultiplier, _ = cfg.Section("").Key("WaveMultiplier").Int()
 		RotationSpeed, _ = cfg.Section("").Key("RotationSpeed").Float64()
 		MoonOrbitRatio, _ = cfg.Section("").Key("MoonOrbitRatio").Float64()
+		MoonOrbitSpeed = RotationSpeed / MoonOrbitRatio // older configs only set the ratio
+		if cfg.Section("").HasKey("MoonOrbitSpeed") {
+			MoonOrbitSpeed, _ = cfg.Section("").Key("MoonOrbitSpeed").Float64()
+		}
 		MoonOrbitDistance, _ = cfg.Section("").Key("MoonOrbitDistance").Float64()
 		AsteroidSpinRatio, _ = cfg.Section("").Key("AsteroidSpinRatio").Float64()
+		Controls = cfg.Section("").Key("Controls").In(Controls, []string{"right", "left"})
+		HudTheme = cfg.Section("").Key("HudTheme").In(HudTheme, hudThemeNames)
+		CrosshairColour = cfg.Section("").Key("CrosshairColour").In(CrosshairColour, []string{"white", "green", "blue", "gold"})
+		AimMode = cfg.Section("").Key("AimMode").In(AimMode, []string{"absolute", "relative"})
+		CrosshairSpeed = cfg.Section("").Key("CrosshairSpeed").MustFloat64(CrosshairSpeed)
+		Brightness = clamp(cfg.Section("").Key("Brightness").MustFloat64(Brightness), 0.5, 1.5)
+		ShaderPreset = cfg.Section("").Key("Shader").In(ShaderPreset, []string{"none", "crt", "aberration"})
+		ReducedMotion = cfg.Section("").Key("ReducedMotion").MustBool(ReducedMotion)
+		Bloom = cfg.Section("").Key("Bloom").MustBool(Bloom)
+		BloomSize = clamp(cfg.Section("").Key("BloomSize").MustFloat64(BloomSize), 0.5, 4)
+		BloomIntensity = clamp(cfg.Section("").Key("BloomIntensity").MustFloat64(BloomIntensity), 0, 1)
+		Debug = cfg.Section("").Key("Debug").MustBool(Debug)
+		SnapToGrid = cfg.Section("").Key("SnapToGrid").MustBool(SnapToGrid)
+		NoMoon = cfg.Section("").Key("NoMoon").MustBool(NoMoon)
+		Practice = cfg.Section("").Key("Practice").MustBool(Practice)
+		Filter = cfg.Section("").Key("Filter").In(Filter, []string{"nearest", "linear"})
+		BehindTheMoon = cfg.Section("").Key("BehindTheMoon").MustBool(BehindTheMoon)
+		RunModifiers = cfg.Section("").Key("RunModifiers").MustBool(RunModifiers)
+		ChainChance = clamp(cfg.Section("").Key("ChainChance").MustFloat64(ChainChance), 0, maxChainChance)
+		ChainPerWave = clamp(cfg.Section("").Key("ChainPerWave").MustFloat64(ChainPerWave), 0, 1)
+		FuseChance = clamp(cfg.Section("").Key("FuseChance").MustFloat64(FuseChance), 0, 1)
+		FuseSeconds = clamp(cfg.Section("").Key("FuseSeconds").MustFloat64(FuseSeconds), 1, 60)
+		BurstRadius = clamp(cfg.Section("").Key("BurstRadius").MustFloat64(BurstRadius), 0, 1000)
+		ScoreBase = clamp(cfg.Section("").Key("ScoreBase").MustFloat64(ScoreBase), 1, 100)
+		ScorePerWave = clamp(cfg.Section("").Key("ScorePerWave").MustFloat64(ScorePerWave), 0, 100)
+		ScoreDistance = clamp(cfg.Section("").Key("ScoreDistance").MustFloat64(ScoreDistance), 0, 100)
+		ScoreFused = clamp(cfg.Section("").Key("ScoreFused").MustFloat64(ScoreFused), 0, 100)
+		AutoScreenshot = cfg.Section("").Key("AutoScreenshot").MustBool(AutoScreenshot)
+		CalmSeconds = clamp(cfg.Section("").Key("CalmSeconds").MustFloat64(CalmSeconds), 0, 60)
+		CalmStart = clamp(cfg.Section("").Key("CalmStart").MustFloat64(CalmStart), 0.1, 1)
+		TurretArc = cfg.Section("").Key("TurretArc").MustBool(TurretArc)
+		ArcDegrees = clamp(cfg.Section("").Key("ArcDegrees").MustFloat64(ArcDegrees), 10, 360)
+		DumpFile = cfg.Section("").Key("DumpFile").MustString(DumpFile)
+		LockOn = cfg.Section("").Key("LockOn").MustBool(LockOn)
+		Eclipses = cfg.Section("").Key("Eclipses").MustBool(Eclipses)
+		EclipseBlind = cfg.Section("").Key("EclipseBlind").MustBool(EclipseBlind)
+		SunAngle = cfg.Section("").Key("SunAngle").MustFloat64(SunAngle)
+		NearMiss = cfg.Section("").Key("NearMiss").In(NearMiss, []string{"off", "split", "speed"})
+		NearMissMargin = clamp(cfg.Section("").Key("NearMissMargin").MustFloat64(NearMissMargin), 0, 200)
+		NearMissBoost = clamp(cfg.Section("").Key("NearMissBoost").MustFloat64(NearMissBoost), 1, 5)
+		Atmosphere = clamp(cfg.Section("").Key("Atmosphere").MustFloat64(Atmosphere), 1, 500)
+		BurnRate = clamp(cfg.Section("").Key("BurnRate").MustFloat64(BurnRate), 0, 100)
+		BurnScore = cfg.Section("").Key("BurnScore").MustInt(BurnScore)
+		AsteroidSpeed = clamp(cfg.Section("").Key("AsteroidSpeed").MustFloat64(AsteroidSpeed), 0.1, 10)
+		Level = cfg.Section("").Key("Level").MustString(Level)
+		AsteroidHealth = cfg.Section("").Key("AsteroidHealth").MustInt(AsteroidHealth)
+		HealthEvery = cfg.Section("").Key("HealthEvery").MustInt(HealthEvery)
+		MaxHealth = cfg.Section("").Key("MaxHealth").MustInt(MaxHealth)
+		StudyMode = cfg.Section("").Key("StudyMode").MustBool(StudyMode)
+		StudyFile = cfg.Section("").Key("StudyFile").MustString(StudyFile)
+		MaxSounds = cfg.Section("").Key("MaxSounds").MustInt(MaxSounds)
+		RadialMenu = cfg.Section("").Key("RadialMenu").MustBool(RadialMenu)
+		IncomingVolume = clamp(cfg.Section("").Key("IncomingVolume").MustFloat64(IncomingVolume), 0, 1)
+		IncomingRange = clamp(cfg.Section("").Key("IncomingRange").MustFloat64(IncomingRange), 0, 2000)
+		MultiHit = cfg.Section("").Key("MultiHit").MustBool(MultiHit)
+		TractorBeam = cfg.Section("").Key("TractorBeam").MustBool(TractorBeam)
+		TractorSeconds = clamp(cfg.Section("").Key("TractorSeconds").MustFloat64(TractorSeconds), 0.5, 10)
+		MinVisibleTime = clamp(cfg.Section("").Key("MinVisibleTime").MustFloat64(MinVisibleTime), 0, 10)
+		CourtesySlow = cfg.Section("").Key("CourtesySlow").MustBool(CourtesySlow)
+		CourtesySlowTime = clamp(cfg.Section("").Key("CourtesySlowTime").MustFloat64(CourtesySlowTime), 0, 2)
+		BeltEvery = cfg.Section("").Key("BeltEvery").MustInt(BeltEvery)
+		BeltSize = cfg.Section("").Key("BeltSize").MustInt(BeltSize)
+		if BeltSize < 1 {
+			BeltSize = 1
+		}
+		BeltSpeed = clamp(cfg.Section("").Key("BeltSpeed").MustFloat64(BeltSpeed), 0.1, 1)
+		RandomRotation = cfg.Section("").Key("RandomRotation").MustBool(RandomRotation)
+		GravityAssist = cfg.Section("").Key("GravityAssist").MustBool(GravityAssist)
+		GravityReach = clamp(cfg.Section("").Key("GravityReach").MustFloat64(GravityReach), 1, 10)
+		GravityStrength = clamp(cfg.Section("").Key("GravityStrength").MustFloat64(GravityStrength), 0, 0.1)
+		MoonGravity = cfg.Section("").Key("MoonGravity").MustBool(MoonGravity)
+		MoonPull = clamp(cfg.Section("").Key("MoonPull").MustFloat64(MoonPull), 0, 1)
+		Rewinds = cfg.Section("").Key("Rewinds").MustInt(Rewinds)
+		WaveHeal = cfg.Section("").Key("WaveHeal").MustBool(WaveHeal)
+		Easy = cfg.Section("").Key("Easy").MustBool(Easy)
+		PanicButton = cfg.Section("").Key("PanicButton").MustBool(PanicButton)
+		PanicRadius = clamp(cfg.Section("").Key("PanicRadius").MustFloat64(PanicRadius), 0, 1000)
+		TimeAttack = cfg.Section("").Key("TimeAttack").MustBool(TimeAttack)
+		TimeAttackSeconds = clamp(cfg.Section("").Key("TimeAttackSeconds").MustFloat64(TimeAttackSeconds), 1, 600)
+		ShowOrbit = cfg.Section("").Key("ShowOrbit").MustBool(ShowOrbit)
+		ShowCoverage = cfg.Section("").Key("ShowCoverage").MustBool(ShowCoverage)
+		EasyMoonReach = clamp(cfg.Section("").Key("EasyMoonReach").MustFloat64(EasyMoonReach), 1, 4)
+		DangerCount = cfg.Section("").Key("DangerCount").MustInt(DangerCount)
+		DangerDistance = cfg.Section("").Key("DangerDistance").MustFloat64(DangerDistance)
+		WaveTints = cfg.Section("").Key("WaveTints").MustString(WaveTints)
+		BehindMoonSpread = cfg.Section("").Key("BehindMoonSpread").MustFloat64(BehindMoonSpread)
+		if Practice {
+			HowManyStart = 1
+		}
+		MaxRotationSpeed = cfg.Section("").Key("MaxRotationSpeed").MustFloat64(MaxRotationSpeed)
+		MaxSpinSpeed = cfg.Section("").Key("MaxSpinSpeed").MustFloat64(MaxSpinSpeed)
+		Volume = clamp(cfg.Section("").Key("Volume").MustFloat64(Volume), 0, 1)
+		GameOverDelay = clamp(cfg.Section("").Key("GameOverDelay").MustFloat64(GameOverDelay), 0, 10)
+		FocusSensitivity = clamp(cfg.Section("").Key("FocusSensitivity").MustFloat64(FocusSensitivity), 0.05, 1)
+	}
+}
+
+// capSpeed limits how far something turns in a tick to limit in either
+// direction, which is halved for reduced motion
+func capSpeed(speed, limit float64) float64 {
+	if ReducedMotion {
+		limit /= 2
+	}
+	return clamp(speed, -limit, limit)
+}
+
+// clamp limits v to the range lo to hi
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// drawTestPattern draws a row of grey swatches centred at x, y, from black to
+// white, which should all be distinguishable on a well calibrated display
+func drawTestPattern(screen *ebiten.Image, x, y int) {
+	const swatches, size = 8, 32
+	left := x - swatches*size/2
+	for i := 0; i < swatches; i++ {
+		v := uint8(i * 255 / (swatches - 1))
+		ebitenutil.DrawRect(
+			screen,
+			float64(left+i*size), float64(y),
+			size, size,
+			color.RGBA{v, v, v, 255},
+		)
 	}
 }
 
@@ -396,6 +1284,11 @@ type Sounds struct {
 	ExplsnMid *audio.Player
 	ExplsnLo  *audio.Player
 	Music     *audio.Player
+	Intensity *audio.Player // extra music layer that fades in with the threat
+	Warning   *audio.Player // blip when an asteroid comes into view
+	WarnPan   *panStream    // which side the warning blip comes from
+	Danger    *audio.Player // alarm when too many asteroids are close at once
+	Incoming  *audio.Player // hum that gets louder as asteroids get closer
 }
 
 func NewSounds() *Sounds {
@@ -407,15 +1300,82 @@ func NewSounds() *Sounds {
 	if err != nil {
 		log.Fatalf("error making music player: %v\n", err)
 	}
-	musicPlayer.SetVolume(0.5)
+	musicPlayer.SetVolume(0.5 * Volume)
+
+	// The intensity layer loops with exactly the same length as the music and
+	// starts at the same time, so they stay in phase
+	pulse := &pulseStream{sampleRate: sampleRate, length: music.Length()}
+	pulseLoop := audio.NewInfiniteLoop(pulse, pulse.length)
+	intensityPlayer, err := audio.NewPlayer(audioConext, pulseLoop)
+	if err != nil {
+		log.Fatalf("error making music intensity player: %v\n", err)
+	}
+	intensityPlayer.SetVolume(0)
+
+	warnPan := &panStream{ReadSeeker: bytes.NewReader(warningBlip(sampleRate))}
+	warningPlayer, err := audio.NewPlayer(audioConext, warnPan)
+	if err != nil {
+		log.Fatalf("error making warning player: %v\n", err)
+	}
+	warningPlayer.SetVolume(0.5 * Volume)
+
+	dangerPlayer, err := audio.NewPlayer(audioConext, bytes.NewReader(dangerAlarm(sampleRate)))
+	if err != nil {
+		log.Fatalf("error making danger alarm player: %v\n", err)
+	}
+	dangerPlayer.SetVolume(0.5 * Volume)
+
+	hum := incomingHum(sampleRate)
+	humLoop := audio.NewInfiniteLoop(bytes.NewReader(hum), int64(len(hum)))
+	incomingPlayer, err := audio.NewPlayer(audioConext, humLoop)
+	if err != nil {
+		log.Fatalf("error making incoming hum player: %v\n", err)
+	}
+	incomingPlayer.SetVolume(0)
+
 	musicPlayer.Play()
+	intensityPlayer.Play()
+	incomingPlayer.Play()
 	return &Sounds{
+		Warning:   warningPlayer,
+		WarnPan:   warnPan,
+		Danger:    dangerPlayer,
+		Incoming:  incomingPlayer,
 		Laser:     loadSound("assets/laser.ogg", audioConext),
 		ExplsnHi:  loadSound("assets/explsn-hi.ogg", audioConext),
 		ExplsnMid: loadSound("assets/explsn-mid.ogg", audioConext),
 		ExplsnLo:  loadSound("assets/explsn-lo.ogg", audioConext),
 		Music:     musicPlayer,
+		Intensity: intensityPlayer,
+	}
+}
+
+// UpdateMusic fades the intensity layer towards the current threat level
+func (s *Sounds) UpdateMusic(threat float64) {
+	const maxVolume, fade = 0.5, 0.02
+	if s.Intensity == nil {
+		return
+	}
+	v := s.Intensity.Volume()
+	s.Intensity.SetVolume(v + (threat*maxVolume*Volume-v)*fade)
+}
+
+// Warn plays the warning blip panned towards pan, from -1 on the left to 1 on
+// the right
+func (s *Sounds) Warn(pan float64) {
+	if s.Warning == nil {
+		return
+	}
+	s.WarnPan.SetPan(clamp(pan, -1, 1))
+	play(s.Warning)
+}
+
+// play plays a sound effect from the start, a nil player is silent
+func play(p *audio.Player) {
+	if p == nil {
+		return
 	}
+	effects.play(p)
 }
 
 func loadSound(name string, context *audio.Context) *audio.Player {
@@ -424,6 +1384,7 @@ func loadSound(name string, context *audio.Context) *audio.Player {
 	if err != nil {
 		log.Fatalf("error making audio player for %s: %v\n", name, err)
 	}
+	audioPlayer.SetVolume(Volume)
 	return audioPlayer
 }
 
@@ -439,3 +1400,118 @@ func loadSoundFile(name string, context *audio.Context) *vorbis.Stream {
 	}
 	return music
 }
+
+// loadShader compiles a post-processing shader preset from the embedded FS, a
+// preset that is missing or won't compile is logged and means no shader
+func loadShader(preset string) *ebiten.Shader {
+	if preset == "none" {
+		return nil
+	}
+	name := fmt.Sprintf("assets/%s.kage", preset)
+	src, err := assets.ReadFile(name)
+	if err != nil {
+		log.Printf("error opening file %s: %v\n", name, err)
+		return nil
+	}
+	return compileShader(name, src)
+}
+
+// compileShader compiles Kage source, falling back to no shader on error
+func compileShader(name string, src []byte) *ebiten.Shader {
+	shader, err := ebiten.NewShader(src)
+	if err != nil {
+		log.Printf("error compiling shader %s: %v\n", name, err)
+		return nil
+	}
+	return shader
+}
+
+// A pulseStream is a low throbbing hum generated as 16-bit stereo PCM, used as
+// the high intensity music layer
+type pulseStream struct {
+	sampleRate int
+	length     int64 // in bytes
+	pos        int64
+}
+
+// Read fills buf with the next samples of the hum
+func (s *pulseStream) Read(buf []byte) (int, error) {
+	const bytesPerSample = 4
+	if s.pos >= s.length {
+		return 0, io.EOF
+	}
+	n := len(buf) / bytesPerSample * bytesPerSample
+	if rest := s.length - s.pos; int64(n) > rest {
+		n = int(rest)
+	}
+	for i := 0; i < n; i += bytesPerSample {
+		t := float64((s.pos+int64(i))/bytesPerSample) / float64(s.sampleRate)
+		beat := math.Pow(math.Sin(math.Pi*t*2), 8) // twice a second
+		v := int16(math.Sin(2*math.Pi*55*t) * beat * 0.6 * math.MaxInt16)
+		buf[i], buf[i+1] = byte(v), byte(v>>8)
+		buf[i+2], buf[i+3] = byte(v), byte(v>>8)
+	}
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Seek moves to another point in the hum
+func (s *pulseStream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		s.pos = offset
+	case io.SeekCurrent:
+		s.pos += offset
+	case io.SeekEnd:
+		s.pos = s.length + offset
+	}
+	return s.pos, nil
+}
+
+// A panStream moves 16-bit stereo PCM from its source towards one side
+type panStream struct {
+	io.ReadSeeker
+	pan uint64 // math.Float64bits of the pan, set by the game as the audio reads it
+}
+
+// SetPan moves the stream to -1 for left only, 0 for the middle or 1 for right
+// only, or anywhere between
+func (s *panStream) SetPan(pan float64) {
+	atomic.StoreUint64(&s.pan, math.Float64bits(pan))
+}
+
+// Pan is where SetPan last moved the stream to
+func (s *panStream) Pan() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.pan))
+}
+
+// Read reads from the source and turns down the channel being panned away from
+func (s *panStream) Read(buf []byte) (int, error) {
+	n, err := s.ReadSeeker.Read(buf)
+	pan := s.Pan()
+	left, right := math.Min(1, 1-pan), math.Min(1, 1+pan)
+	for i := 0; i+3 < n; i += 4 {
+		l := float64(int16(buf[i]) | int16(buf[i+1])<<8)
+		r := float64(int16(buf[i+2]) | int16(buf[i+3])<<8)
+		lv, rv := int16(l*left), int16(r*right)
+		buf[i], buf[i+1] = byte(lv), byte(lv>>8)
+		buf[i+2], buf[i+3] = byte(rv), byte(rv>>8)
+	}
+	return n, err
+}
+
+// warningBlip generates a short falling beep as 16-bit stereo PCM
+func warningBlip(sampleRate int) []byte {
+	const seconds = 0.15
+	samples := int(seconds * float64(sampleRate))
+	buf := make([]byte, samples*4)
+	for i := 0; i < samples; i++ {
+		t := float64(i) / float64(sampleRate)
+		freq := 880 - 440*t/seconds
+		fade := 1 - t/seconds
+		v := int16(math.Sin(2*math.Pi*freq*t) * fade * 0.5 * math.MaxInt16)
+		buf[i*4], buf[i*4+1] = byte(v), byte(v>>8)
+		buf[i*4+2], buf[i*4+3] = byte(v), byte(v>>8)
+	}
+	return buf
+}
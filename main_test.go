@@ -0,0 +1,1201 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestLoadShader(t *testing.T) {
+	if loadShader("none") != nil {
+		t.Errorf("preset none gave a shader")
+	}
+	for _, preset := range []string{"crt", "aberration"} {
+		if loadShader(preset) == nil {
+			t.Errorf("preset %s didn't compile", preset)
+		}
+	}
+	if loadShader("missing") != nil {
+		t.Errorf("missing preset gave a shader")
+	}
+	if compileShader("broken", []byte("package main\nfunc Fragment(")) != nil {
+		t.Errorf("broken shader gave a shader instead of falling back")
+	}
+}
+
+func TestThreatLevel(t *testing.T) {
+	g := &Game{Earth: &Earth{Object: NewObject("assets/earth.png")}}
+	img := ebiten.NewImage(16, 16)
+	if got := g.ThreatLevel(); got != 0 {
+		t.Errorf("threat with no asteroids is %v", got)
+	}
+
+	far := g.Earth.Radius * EdgeOfScreenOffset
+	g.Asteroids = NewAsteroids(img, img, rand.New(rand.NewSource(1)), g.Earth.Radius, 2)
+	for _, v := range g.Asteroids {
+		v.Distance = far
+	}
+	if got := g.ThreatLevel(); got != 0 {
+		t.Errorf("threat with distant asteroids is %v", got)
+	}
+
+	g.Asteroids[0].Distance = far / 2
+	one := g.ThreatLevel()
+	g.Asteroids[1].Distance = far / 2
+	two := g.ThreatLevel()
+	if one <= 0 || two <= one {
+		t.Errorf("threat didn't rise with closer asteroids: %v then %v", one, two)
+	}
+
+	g.Asteroids = NewAsteroids(img, img, rand.New(rand.NewSource(1)), g.Earth.Radius, 10)
+	for _, v := range g.Asteroids {
+		v.Distance = 0
+	}
+	if got := g.ThreatLevel(); got != 1 {
+		t.Errorf("threat with many impacting asteroids is %v, want 1", got)
+	}
+}
+
+func TestPulseStreamLength(t *testing.T) {
+	s := &pulseStream{sampleRate: 44100, length: 4 * 1000}
+	buf := make([]byte, 1024)
+	total := 0
+	for {
+		n, err := s.Read(buf)
+		total += n
+		if err != nil {
+			break
+		}
+	}
+	if total != 4000 {
+		t.Errorf("read %d bytes, want the whole length of 4000", total)
+	}
+}
+
+func TestRestartSpawnsHowMany(t *testing.T) {
+	img := ebiten.NewImage(16, 16)
+	for _, howMany := range []int{1, 3, 12} {
+		g := &Game{
+			Earth:    &Earth{Object: NewObject("assets/earth.png")},
+			Entities: []Entity{Asteroids{}},
+			HowMany:  howMany,
+			Images:   map[string]*ebiten.Image{"asteroid": img, "explosion": img},
+		}
+		g.Restart()
+		if len(g.Asteroids) != howMany || g.Count != howMany {
+			t.Errorf("restart with %d spawned %d asteroids, count %d", howMany, len(g.Asteroids), g.Count)
+		}
+		if len(g.Entities[0].(Asteroids)) != howMany {
+			t.Errorf("restart with %d didn't put the asteroids in the entities", howMany)
+		}
+	}
+}
+
+func TestWindowSize(t *testing.T) {
+	tests := []struct {
+		scale float64
+		w, h  int
+	}{
+		{1, 640, 480},
+		{2, 640, 480},
+		{1.5, 427, 320},
+		{3, 427, 320},
+		{0, 640, 480},
+	}
+	for _, tt := range tests {
+		w, h := windowSize(1280, 960, 640, 480, tt.scale)
+		if w != tt.w || h != tt.h {
+			t.Errorf("scale %v: window %dx%d, want %dx%d", tt.scale, w, h, tt.w, tt.h)
+		}
+	}
+
+	// At 2x, the cursor over a physical pixel is over that same game pixel, so
+	// the crosshair lines up with it exactly
+	w, h := windowSize(1280, 960, 640, 480, 2)
+	for _, tt := range []struct{ physX, physY, gameX, gameY int }{
+		{0, 0, 0, 0},
+		{640, 480, 640, 480},
+		{1000, 700, 1000, 700},
+		{1279, 959, 1279, 959},
+	} {
+		x := float64(tt.physX) / 2 * 1280 / float64(w) // physical to window, to game
+		y := float64(tt.physY) / 2 * 960 / float64(h)
+		if int(x) != tt.gameX || int(y) != tt.gameY {
+			t.Errorf("cursor over physical pixel %d, %d is at game pixel %v, %v, want %d, %d",
+				tt.physX, tt.physY, x, y, tt.gameX, tt.gameY)
+		}
+	}
+}
+
+func TestGameOverDelay(t *testing.T) {
+	defer func(d float64) { GameOverDelay = d }(GameOverDelay)
+	GameOverDelay = 0.05
+
+	now := time.Now()
+	g := &Game{Save: &SaveFile{}, Sounds: &Sounds{}, Clock: func() time.Time { return now }}
+	g.EndGame()
+	if !g.GameOver {
+		t.Fatalf("game isn't over")
+	}
+	if g.CanRestart() {
+		t.Errorf("game can restart straight away")
+	}
+
+	now = now.Add(40 * time.Millisecond)
+	if g.CanRestart() {
+		t.Errorf("game can restart before the delay")
+	}
+	now = now.Add(10 * time.Millisecond)
+	if !g.CanRestart() {
+		t.Errorf("game can't restart after the delay")
+	}
+}
+
+func TestBreathBetweenWaves(t *testing.T) {
+	defer func(d int) { TimeBetweenWaves = d }(TimeBetweenWaves)
+	TimeBetweenWaves = 2
+
+	now := time.Now()
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Clock = func() time.Time { return now }
+	g.Sounds = &Sounds{}
+	g.Wave = 2
+	g.HowMany = 3
+	g.Restart()
+	g.Breathless = true
+	g.takeABreath()
+
+	now = now.Add(time.Second)
+	g.updateBreath()
+	if !g.Breathless || g.HowMany != 3 {
+		t.Fatalf("next wave came in before the breath was over")
+	}
+	now = now.Add(time.Second)
+	g.updateBreath()
+	if g.Breathless || g.HowMany != 3*WaveMultiplier || len(g.Asteroids) != g.HowMany {
+		t.Errorf("next wave didn't come in after the breath, %d asteroids", len(g.Asteroids))
+	}
+}
+
+func TestPanStream(t *testing.T) {
+	blip := warningBlip(44100)
+	for _, tt := range []struct {
+		pan         float64
+		left, right bool
+	}{
+		{-1, true, false},
+		{0, true, true},
+		{1, false, true},
+	} {
+		s := &panStream{ReadSeeker: bytes.NewReader(blip)}
+		s.SetPan(tt.pan)
+		buf, err := io.ReadAll(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var left, right bool
+		for i := 0; i+3 < len(buf); i += 4 {
+			left = left || buf[i] != 0 || buf[i+1] != 0
+			right = right || buf[i+2] != 0 || buf[i+3] != 0
+		}
+		if left != tt.left || right != tt.right {
+			t.Errorf("pan %v: sound on the left %v and right %v", tt.pan, left, right)
+		}
+	}
+}
+
+func TestNoMoon(t *testing.T) {
+	defer func(on bool) { NoMoon = on }(NoMoon)
+	NoMoon = true
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 3
+	if g.Moon != nil {
+		t.Fatalf("game has a moon")
+	}
+	for _, e := range g.Entities {
+		if _, ok := e.(*Moon); ok {
+			t.Fatalf("moon in the entities")
+		}
+	}
+
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.Restart()
+	// Spread evenly round the Earth, so only the target is under the crosshair
+	for i, v := range g.Asteroids {
+		v.Angle = 2 * math.Pi * float64(i) / float64(len(g.Asteroids))
+	}
+	for i := 0; i < 10; i++ {
+		g.Asteroids.Update(g)
+		g.Earth.Update(g)
+	}
+
+	target := g.Asteroids[0]
+	g.Crosshair.Center = target.Center
+	g.Crosshair.Shoot(g)
+	if g.Crosshair.ShootingFrom != g.Earth.Center {
+		t.Errorf("shot from %v without a moon, want the Earth at %v", g.Crosshair.ShootingFrom, g.Earth.Center)
+	}
+	if !target.Explosion.Exploding || g.Count != 2 {
+		t.Errorf("shot asteroid not destroyed, %d left", g.Count)
+	}
+}
+
+func TestCapSpeed(t *testing.T) {
+	if got := capSpeed(0.02, 0.1); got != 0.02 {
+		t.Errorf("normal speed capped to %v", got)
+	}
+	if got := capSpeed(1000, 0.1); got != 0.1 {
+		t.Errorf("extreme speed capped to %v, want 0.1", got)
+	}
+	if got := capSpeed(-1000, 0.1); got != -0.1 {
+		t.Errorf("extreme reverse speed capped to %v, want -0.1", got)
+	}
+
+	ReducedMotion = true
+	defer func() { ReducedMotion = false }()
+	if got := capSpeed(1000, 0.1); got != 0.05 {
+		t.Errorf("extreme speed with reduced motion capped to %v, want 0.05", got)
+	}
+}
+
+func TestBehindTheMoon(t *testing.T) {
+	BehindTheMoon = true
+	defer func() { BehindTheMoon = false }()
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 20
+	g.Rotation = -4
+	g.Restart()
+
+	bearing := g.Moon.Bearing()
+	for _, v := range g.Asteroids {
+		diff := math.Remainder(v.Angle-bearing, 2*math.Pi)
+		if math.Abs(diff) > BehindMoonSpread {
+			t.Errorf("asteroid at %v, more than %v from the moon at %v", v.Angle, BehindMoonSpread, bearing)
+		}
+	}
+}
+
+func TestDangerWarning(t *testing.T) {
+	defer func(c int) { DangerCount = c }(DangerCount)
+	DangerCount = 3
+
+	var d DangerWarning
+	if d.Update(2, 0) || d.Active {
+		t.Fatalf("warning with fewer asteroids than the threshold")
+	}
+	if !d.Update(3, 1) || !d.Active {
+		t.Fatalf("no alarm when crossing the threshold")
+	}
+	if d.Update(4, 2) {
+		t.Errorf("alarm again while the warning stayed on")
+	}
+	if d.Update(1, 3) || d.Active {
+		t.Errorf("warning didn't clear once asteroids dropped below the threshold")
+	}
+	if d.Update(3, 4) || !d.Active {
+		t.Errorf("crossing again so soon should warn without another alarm")
+	}
+	d.Update(0, 5)
+	if !d.Update(3, 5+dangerAlarmGap) {
+		t.Errorf("no alarm crossing again after the gap")
+	}
+}
+
+func TestAsteroidsClose(t *testing.T) {
+	as := Asteroids{
+		{Object: &Object{}, Alive: true, Distance: 50, Explosion: &Explosion{}},
+		{Object: &Object{}, Alive: true, Distance: 200, Explosion: &Explosion{}},
+		{Object: &Object{}, Alive: false, Distance: 10, Explosion: &Explosion{}},
+		{Object: &Object{}, Alive: true, Distance: 20, Explosion: &Explosion{Exploding: true}},
+	}
+	if n := as.Close(150); n != 1 {
+		t.Errorf("%d close asteroids, want 1", n)
+	}
+}
+
+// fixedSource is a random source that always gives the same number
+type fixedSource int64
+
+func (s fixedSource) Int63() int64 { return int64(s) }
+func (s fixedSource) Seed(int64)   {}
+
+func TestWithRand(t *testing.T) {
+	const half = 1 << 62 // Float64 of this is 0.5
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(fixedSource(half))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 4
+	g.Restart()
+
+	edge := g.Earth.Radius * EdgeOfScreenOffset
+	wantDistance := edge + 0.5*g.Earth.Radius*4/DistanceVariance
+	for _, v := range g.Asteroids {
+		if v.Angle != math.Pi {
+			t.Errorf("asteroid at angle %v, want %v", v.Angle, math.Pi)
+		}
+		if v.Distance != wantDistance {
+			t.Errorf("asteroid at distance %v, want %v", v.Distance, wantDistance)
+		}
+	}
+}
+
+func TestNewGame(t *testing.T) {
+	loader := memoryLoader{
+		"assets/earth.png":     100,
+		"assets/explosion.png": 30,
+		"assets/crosshair.png": 40,
+		"assets/moon.png":      50,
+		"assets/turret.png":    10,
+		"assets/gameover.png":  200,
+		"assets/asteroid.png":  20,
+	}
+	g, err := NewGame(640, 480, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name         string
+		radius, want float64
+	}{
+		{"earth", g.Earth.Radius, 50},
+		{"crosshair", g.Crosshair.Radius, 20},
+		{"explosion", g.Crosshair.Explosion.Radius, 15},
+		{"moon", g.Moon.Object.Radius, 25},
+		{"turret", g.Moon.Turret.Radius, 5},
+	} {
+		if tt.radius != tt.want {
+			t.Errorf("%s radius %v, want %v", tt.name, tt.radius, tt.want)
+		}
+	}
+	if g.Earth.Center != image.Pt(320, 240) {
+		t.Errorf("earth at %v, want the middle of the screen", g.Earth.Center)
+	}
+
+	delete(loader, "assets/moon.png")
+	if _, err := NewGame(640, 480, loader); err == nil {
+		t.Errorf("no error with a missing image")
+	}
+}
+
+func TestNoSpawningAfterGameOver(t *testing.T) {
+	defer func(ta bool, name string) { TimeAttack, SaveFileName = ta, name }(TimeAttack, SaveFileName)
+	SaveFileName = ""
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 2
+	g.Restart()
+	g.EndGame()
+	before := len(g.Asteroids)
+
+	if g.nextWave() || len(g.Asteroids) != before || g.HowMany != 2 {
+		t.Errorf("a wave came in after game over")
+	}
+	TimeAttack = true
+	for g.Tick = 0; g.Tick < 2*timeAttackSpawnGap; g.Tick++ {
+		g.updateTimeAttack()
+	}
+	if len(g.Asteroids) != before {
+		t.Errorf("%d asteroids spawned in time attack after game over", len(g.Asteroids)-before)
+	}
+
+	g.Restart()
+	if !g.Spawning || !g.nextWave() {
+		t.Errorf("spawning didn't come back after restarting")
+	}
+}
+
+func TestPanic(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 3
+	g.Restart()
+	g.Asteroids[0].Distance = PanicRadius / 2
+	g.Asteroids[1].Distance = PanicRadius - 1
+	g.Asteroids[2].Distance = PanicRadius * 2
+
+	if !g.PanicReady {
+		t.Fatalf("no panic charge at the start of the wave")
+	}
+	if n := g.Panic(); n != 2 || g.Count != 1 {
+		t.Errorf("panic destroyed %d leaving %d, want 2 leaving 1", n, g.Count)
+	}
+	if g.Asteroids[2].Explosion.Exploding || !g.Asteroids[0].Explosion.Exploding {
+		t.Errorf("panic destroyed the wrong asteroids")
+	}
+	if g.PanicReady {
+		t.Errorf("panic charge not used up")
+	}
+
+	g.Asteroids[2].Distance = 0
+	if n := g.Panic(); n != 0 || g.Count != 1 {
+		t.Errorf("panic used twice in a wave destroyed %d", n)
+	}
+
+	g.Restart()
+	if !g.PanicReady {
+		t.Errorf("panic charge not refilled for the next wave")
+	}
+}
+
+func TestRandomRotation(t *testing.T) {
+	defer func(r bool) { RandomRotation = r }(RandomRotation)
+	RandomRotation = true
+
+	start := func(seed int64) float64 {
+		g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g.Rotation
+	}
+	if a, b := start(7), start(7); a != b {
+		t.Errorf("same seed started at rotations %v and %v", a, b)
+	}
+	if a, b := start(7), start(8); a == b {
+		t.Errorf("different seeds both started at rotation %v", a)
+	}
+
+	RandomRotation = false
+	if r := start(7); r != 0 {
+		t.Errorf("fixed start at rotation %v, want 0", r)
+	}
+}
+
+func TestBeltWave(t *testing.T) {
+	defer func(e int) { BeltEvery = e }(BeltEvery)
+	BeltEvery = 2
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 4
+	g.Wave = 1
+	g.Restart()
+	if g.BeltWave() || len(g.Asteroids) != 4 {
+		t.Fatalf("wave 1 is a belt of %d asteroids", len(g.Asteroids))
+	}
+
+	g.Wave = 2
+	g.Restart()
+	n := 4 * BeltSize
+	if !g.BeltWave() || len(g.Asteroids) != n || g.Count != n {
+		t.Fatalf("belt wave has %d asteroids and count %d, want %d", len(g.Asteroids), g.Count, n)
+	}
+	gap := 2 * math.Pi / float64(n)
+	for i, v := range g.Asteroids {
+		if v.Distance != g.Asteroids[0].Distance || v.Speed != BeltSpeed {
+			t.Errorf("asteroid %d at distance %v speed %v, want the whole ring together", i, v.Distance, v.Speed)
+		}
+		if i > 0 {
+			d := v.Angle - g.Asteroids[i-1].Angle
+			if d < gap*(1-2*beltJitter) || d > gap*(1+2*beltJitter) {
+				t.Errorf("asteroids %d and %d are %v apart, want about %v", i-1, i, d, gap)
+			}
+		}
+	}
+
+	before := g.Asteroids[0].Distance
+	g.Asteroids.Update(g)
+	if got := before - g.Asteroids[0].Distance; math.Abs(got-BeltSpeed) > 1e-9 {
+		t.Errorf("belt closed in by %v, want %v", got, BeltSpeed)
+	}
+}
+
+func TestCourtesySlow(t *testing.T) {
+	var r TimeRamp
+	const step = 0.25
+
+	if !r.Advance() {
+		t.Fatalf("stopped before losing focus")
+	}
+
+	// Losing focus ramps down to a stop a step at a time
+	for _, want := range []float64{0.75, 0.5, 0.25, 0, 0} {
+		r.Update(false, step)
+		if math.Abs(r.Scale()-want) > 1e-9 {
+			t.Errorf("unfocused scale %v, want %v", r.Scale(), want)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if r.Advance() {
+			t.Fatalf("world moved while stopped")
+		}
+	}
+
+	// Coming back ramps up again
+	for _, want := range []float64{0.25, 0.5} {
+		r.Update(true, step)
+		if math.Abs(r.Scale()-want) > 1e-9 {
+			t.Errorf("focused scale %v, want %v", r.Scale(), want)
+		}
+	}
+	moved := 0
+	for i := 0; i < 10; i++ {
+		if r.Advance() {
+			moved++
+		}
+	}
+	if moved != 5 {
+		t.Errorf("moved %d of 10 ticks at half speed, want 5", moved)
+	}
+
+	r.Update(true, 1)
+	if r.Scale() != 1 || !r.Advance() {
+		t.Errorf("not back to full speed, scale %v", r.Scale())
+	}
+}
+
+func TestTractorBeam(t *testing.T) {
+	defer func(b bool) { TractorBeam = b }(TractorBeam)
+	TractorBeam = true
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 3
+	g.Restart()
+	g.Asteroids[0].Distance = 500
+	g.Asteroids[1].Distance = 200
+	g.Asteroids[2].Distance = 800
+
+	caught := g.Tractor()
+	if caught != g.Asteroids[1] {
+		t.Fatalf("tractor beam didn't catch the closest asteroid")
+	}
+	if g.Tractor() != nil {
+		t.Errorf("tractor beam used twice in a wave")
+	}
+
+	ticks := caught.Captured
+	for i := 0; i < ticks; i++ {
+		caught.Update(g)
+		if caught.Distance != 200 {
+			t.Fatalf("captured asteroid moved to %v after %d ticks", caught.Distance, i+1)
+		}
+	}
+	caught.Update(g)
+	if caught.Distance >= 200 {
+		t.Errorf("asteroid still held after the capture ended")
+	}
+	if g.Asteroids.Captured() != nil {
+		t.Errorf("asteroid still captured after the capture ended")
+	}
+
+	g.Restart()
+	if !g.TractorOK {
+		t.Errorf("tractor beam not ready for the next wave")
+	}
+}
+
+func TestShootScoresEachHit(t *testing.T) {
+	defer func(m bool) { MultiHit = m }(MultiHit)
+
+	for _, tt := range []struct {
+		multi bool
+		want  int
+	}{{true, 3}, {false, 1}} {
+		MultiHit = tt.multi
+		g, err := NewGame(1280, 960, assetLoader{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Sounds = &Sounds{}
+		g.Wave = 1
+		g.HowMany = 3
+		g.Restart()
+		for i, v := range g.Asteroids {
+			v.Center = g.Crosshair.Center.Add(image.Pt(i, 0))
+		}
+
+		g.Crosshair.Shoot(g)
+		if g.Score != tt.want || g.Stats.Kills != tt.want || g.Count != 3-tt.want {
+			t.Errorf("multi %v: score %d, kills %d, %d left, want %d destroyed",
+				tt.multi, g.Score, g.Stats.Kills, g.Count, tt.want)
+		}
+		if g.Stats.Hits != 1 {
+			t.Errorf("multi %v: one shot counted as %d hits", tt.multi, g.Stats.Hits)
+		}
+	}
+}
+
+func TestIncomingVolume(t *testing.T) {
+	defer func(v, r float64) { IncomingVolume, IncomingRange = v, r }(IncomingVolume, IncomingRange)
+	IncomingVolume, IncomingRange = 0.4, 400
+
+	for _, tt := range []struct {
+		distance, want float64
+	}{
+		{math.Inf(1), 0},
+		{800, 0},
+		{400, 0},
+		{200, 0.2},
+		{0, 0.4},
+		{-10, 0.4},
+	} {
+		if got := incomingVolume(tt.distance); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("volume at %v is %v, want %v", tt.distance, got, tt.want)
+		}
+	}
+
+	as := Asteroids{
+		{Distance: 300, Alive: true, Explosion: &Explosion{}},
+		{Distance: 100, Alive: true, Explosion: &Explosion{Exploding: true}},
+		{Distance: 50, Alive: false, Explosion: &Explosion{}},
+		{Distance: 200, Alive: true, Explosion: &Explosion{}},
+	}
+	if got := as.Nearest(); got != 200 {
+		t.Errorf("nearest asteroid at %v, want 200", got)
+	}
+	if got := (Asteroids{}).Nearest(); !math.IsInf(got, 1) {
+		t.Errorf("nearest with no asteroids at %v, want infinity", got)
+	}
+}
+
+func TestRadialChoice(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		dx, dy float64
+		n      int
+		want   int
+	}{
+		{"too short a move", 5, 5, 2, -1},
+		{"no abilities", 0, -100, 0, -1},
+		{"up of two", 0, -100, 2, 0},
+		{"down of two", 0, 100, 2, 1},
+		{"right leaning up of two", 100, -10, 2, 0},
+		{"right leaning down of two", 100, 10, 2, 1},
+		{"up of four", 10, -100, 4, 0},
+		{"right of four", 100, 0, 4, 1},
+		{"down of four", 0, 100, 4, 2},
+		{"left of four", -100, 0, 4, 3},
+		{"up left of four", -10, -100, 4, 0},
+	} {
+		if got := radialChoice(tt.dx, tt.dy, tt.n); got != tt.want {
+			t.Errorf("%s: picked %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAsteroidHealth(t *testing.T) {
+	defer func(base, every, max int) {
+		AsteroidHealth, HealthEvery, MaxHealth = base, every, max
+	}(AsteroidHealth, HealthEvery, MaxHealth)
+	AsteroidHealth, HealthEvery, MaxHealth = 1, 3, 3
+
+	for _, tt := range []struct{ wave, want int }{
+		{1, 1}, {3, 1}, {4, 2}, {6, 2}, {7, 3}, {10, 3}, {40, 3},
+	} {
+		if got := asteroidHealth(tt.wave); got != tt.want {
+			t.Errorf("wave %d asteroids have %d health, want %d", tt.wave, got, tt.want)
+		}
+	}
+	HealthEvery = 0
+	if got := asteroidHealth(40); got != 1 {
+		t.Errorf("health went up to %d without scaling", got)
+	}
+
+	// A tougher asteroid takes more than one shot, without cooling down
+	HealthEvery = 3
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 4
+	g.HowMany = 1
+	g.Restart()
+	a := g.Asteroids[0]
+	if a.Health != 2 {
+		t.Fatalf("wave 4 asteroid spawned with %d health, want 2", a.Health)
+	}
+	a.Center = g.Crosshair.Center
+	g.Crosshair.Shoot(g)
+	if a.Explosion.Exploding || g.Score != 0 || g.Crosshair.CoolingDown || g.Stats.Hits != 0 {
+		t.Errorf("first shot destroyed the asteroid or counted as a miss or a hit")
+	}
+	g.Crosshair.Shoot(g)
+	if !a.Explosion.Exploding || g.Score != 1 || g.Count != 0 || g.Stats.Hits != 1 {
+		t.Errorf("second shot didn't destroy the asteroid or wasn't counted as a hit")
+	}
+}
+
+func TestLeftHandedControls(t *testing.T) {
+	defer setControls("right")
+
+	s := &SaveFile{Controls: "left"}
+	setControls(s.Handed("right"))
+	if controls.Buttons[ActionShoot] != ebiten.MouseButtonRight || controls.Buttons[ActionFocus] != ebiten.MouseButtonLeft {
+		t.Errorf("left-handed preset didn't swap the mouse buttons")
+	}
+	for a, want := range map[Action]ebiten.Key{
+		ActionPanic:     ebiten.KeyP,
+		ActionTractor:   ebiten.KeyO,
+		ActionAbilities: ebiten.KeyI,
+		ActionLock:      ebiten.KeyU,
+	} {
+		if controls.Keys[a] != want {
+			t.Errorf("left-handed action %d on %v, want %v", a, controls.Keys[a], want)
+		}
+	}
+	if keyName(ActionPanic) != "P" {
+		t.Errorf("panic key shown as %q, want P", keyName(ActionPanic))
+	}
+
+	s.Controls = ""
+	setControls(s.Handed("right"))
+	if controls.Buttons[ActionShoot] != ebiten.MouseButtonLeft || controls.Keys[ActionPanic] != ebiten.KeyB {
+		t.Errorf("default controls aren't right-handed")
+	}
+}
+
+func TestChainSpawn(t *testing.T) {
+	defer func(c, w float64) { ChainChance, ChainPerWave = c, w }(ChainChance, ChainPerWave)
+	ChainChance, ChainPerWave = 0.3, 0.1
+
+	if got := chainChance(3); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("wave 3 chain chance %v, want 0.5", got)
+	}
+	if got := chainChance(20); got != maxChainChance {
+		t.Errorf("chain chance %v, should stop at %v", got, maxChainChance)
+	}
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 3
+	g.HowMany = 1
+	g.Restart()
+
+	const tries = 2000
+	spawned := 0
+	for i := 0; i < tries; i++ {
+		if g.chainSpawn() {
+			spawned++
+		}
+	}
+	if spawned < tries*45/100 || spawned > tries*55/100 {
+		t.Errorf("%d of %d destroyed asteroids replaced, want about half", spawned, tries)
+	}
+	if len(g.Asteroids) != 1+spawned || g.Count != 1+spawned {
+		t.Errorf("%d asteroids and count %d after %d replacements", len(g.Asteroids), g.Count, spawned)
+	}
+
+	// Always below the chance, never above it
+	g.Rand = rand.New(fixedSource(1 << 62)) // Float64 of this is 0.5
+	ChainChance, ChainPerWave = 0.5, 0
+	if g.chainSpawn() {
+		t.Errorf("replaced with a roll equal to the chance")
+	}
+	ChainChance = 0.51
+	if !g.chainSpawn() {
+		t.Errorf("not replaced with a roll under the chance")
+	}
+}
+
+func TestChainEnds(t *testing.T) {
+	defer func(c, w float64) { ChainChance, ChainPerWave = c, w }(ChainChance, ChainPerWave)
+	ChainChance, ChainPerWave = 1, 1
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 50
+	g.HowMany = 1
+	g.Restart()
+
+	// Destroying asteroids as fast as they come in still clears the wave
+	for destroyed := 0; g.Count > 0; destroyed++ {
+		if destroyed > 1000 {
+			t.Fatalf("wave %d still going after %d asteroids destroyed", g.Wave, destroyed)
+		}
+		g.Count--
+		g.chainSpawn()
+	}
+}
+
+func TestHelpPages(t *testing.T) {
+	n := len(helpPages())
+	for _, tt := range []struct {
+		page, step, want int
+		open             bool
+	}{
+		{0, 0, 0, true},
+		{0, 1, 1, true},
+		{1, -1, 0, true},
+		{0, -1, 0, true},
+		{n - 1, 1, n, false},
+	} {
+		page, open := turnHelpPage(tt.page, tt.step, n)
+		if page != tt.want || open != tt.open {
+			t.Errorf("page %d turned %d went to %d open %v, want %d open %v", tt.page, tt.step, page, open, tt.want, tt.open)
+		}
+	}
+
+	// The controls page follows the bindings
+	defer setControls("right")
+	setControls("left")
+	found := false
+	for _, p := range helpPages() {
+		for _, line := range p.Lines {
+			if line == "RIGHT CLICK: SHOOT" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("how to play doesn't show left-handed shooting")
+	}
+}
+
+func TestFuseBurst(t *testing.T) {
+	defer func(r float64) { BurstRadius = r }(BurstRadius)
+	BurstRadius = 150
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 4
+	g.Restart()
+
+	fused, near, tough, far := g.Asteroids[0], g.Asteroids[1], g.Asteroids[2], g.Asteroids[3]
+	place := func(a *Asteroid, angle, distance float64) {
+		a.Angle, a.Distance, a.Speed = angle, distance, 0
+		a.Update(g)
+	}
+	place(fused, 0, 300)
+	place(near, 0, 400)
+	place(tough, 0.1, 300)
+	place(far, math.Pi, 300)
+	tough.Health = 2
+	fused.Fuse = 2
+
+	fused.Update(g)
+	if fused.Explosion.Exploding || fused.Fuse != 1 {
+		t.Fatalf("burst with fuse %d left", fused.Fuse)
+	}
+	fused.Update(g)
+	if !fused.Explosion.Exploding || fused.Impacting {
+		t.Fatalf("fuse ran out but it didn't burst on its own")
+	}
+	if !near.Explosion.Exploding {
+		t.Errorf("asteroid within the burst radius wasn't destroyed")
+	}
+	if tough.Explosion.Exploding || tough.Health != 1 {
+		t.Errorf("tougher asteroid in the burst has health %d, want 1", tough.Health)
+	}
+	if far.Explosion.Exploding || far.Health != 1 {
+		t.Errorf("asteroid outside the burst radius was hit")
+	}
+	if g.Count != 2 {
+		t.Errorf("%d asteroids left after the burst, want 2", g.Count)
+	}
+
+	// Bursting close to the Earth hits it
+	place(far, math.Pi, 100)
+	g.Burst(far)
+	if !far.Impacting {
+		t.Errorf("burst within reach of the Earth didn't hit it")
+	}
+}
+
+func TestScoreFor(t *testing.T) {
+	defer func(base, wave, distance, fused float64) {
+		ScoreBase, ScorePerWave, ScoreDistance, ScoreFused = base, wave, distance, fused
+	}(ScoreBase, ScorePerWave, ScoreDistance, ScoreFused)
+
+	tests := []struct {
+		name                       string
+		base, perWave, dist, fused float64
+		wave                       int
+		distance                   float64
+		fuse                       int
+		want                       int
+	}{
+		{"defaults", 1, 0, 0, 0, 5, 400, 60, 1},
+		{"first wave", 1, 2, 0, 0, 1, 400, 0, 1},
+		{"later wave", 1, 2, 0, 0, 4, 400, 0, 7},
+		{"far out", 1, 0, 0.5, 0, 1, 500, 0, 4},
+		{"at the earth", 1, 0, 0.5, 0, 1, 0, 0, 1},
+		{"fused", 1, 0, 0, 3, 1, 400, 60, 4},
+		{"defused", 1, 0, 0, 3, 1, 400, 0, 1},
+		{"everything", 2, 1, 1, 5, 3, 250, 1, 12},
+		{"never nothing", 0, 0, 0, 0, 1, 400, 0, 1},
+	}
+	for _, tt := range tests {
+		ScoreBase, ScorePerWave, ScoreDistance, ScoreFused = tt.base, tt.perWave, tt.dist, tt.fused
+		g := &Game{Wave: tt.wave}
+		a := &Asteroid{Distance: tt.distance, Fuse: tt.fuse}
+		if got := scoreFor(a, g); got != tt.want {
+			t.Errorf("%s: scored %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalmStart(t *testing.T) {
+	defer func(secs, start float64) { CalmSeconds, CalmStart = secs, start }(CalmSeconds, CalmStart)
+	CalmSeconds, CalmStart = 2, 0.25
+	total := 2 * ebiten.MaxTPS()
+
+	if got := calmFactor(0); got != 0.25 {
+		t.Errorf("calm start begins at %v, want 0.25", got)
+	}
+	last := 0.0
+	for ticks := 0; ticks < total; ticks++ {
+		got := calmFactor(ticks)
+		if got < last || got >= 1 {
+			t.Fatalf("calm start at %v after %d ticks, following %v", got, ticks, last)
+		}
+		last = got
+	}
+	if got := calmFactor(total / 2); math.Abs(got-0.625) > 1e-9 {
+		t.Errorf("calm start halfway at %v, want 0.625", got)
+	}
+	for _, ticks := range []int{total, total + 1, 10 * total} {
+		if got := calmFactor(ticks); got != 1 {
+			t.Errorf("calm start still at %v after %d ticks", got, ticks)
+		}
+	}
+	if got := calmSpawnGap(0); got != 4*timeAttackSpawnGap {
+		t.Errorf("calm start spawn gap %d, want %d", got, 4*timeAttackSpawnGap)
+	}
+
+	CalmSeconds = 0
+	if got := calmFactor(0); got != 1 {
+		t.Errorf("skipped calm start at %v, want 1", got)
+	}
+}
+
+func TestLockOn(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 2
+	g.Restart()
+	a, other := g.Asteroids[0], g.Asteroids[1]
+	a.Distance, other.Distance = 300, 600
+	a.Update(g)
+	other.Update(g)
+
+	g.Crosshair.Center = image.Pt(5, 5)
+	if g.Lock() != nil || g.Locked != 0 {
+		t.Fatalf("locked on to empty space")
+	}
+	g.Crosshair.Center = a.Center
+	if g.Lock() != a || g.Locked != a.ID {
+		t.Fatalf("didn't lock on to the asteroid under the crosshair")
+	}
+
+	// The lock follows the asteroid as it moves, wherever the crosshair is
+	for i := 0; i < 30; i++ {
+		a.Update(g)
+	}
+	g.Crosshair.Center = other.Center
+	g.Crosshair.Shoot(g)
+	if g.Crosshair.ShootingAt != a.Center || !a.Explosion.Exploding {
+		t.Errorf("shot went to %v, want the locked asteroid at %v", g.Crosshair.ShootingAt, a.Center)
+	}
+	if other.Explosion.Exploding {
+		t.Errorf("shot hit the asteroid under the crosshair instead of the locked one")
+	}
+	if g.LockedTarget() != nil || g.Locked != 0 {
+		t.Errorf("still locked on to asteroid %d after destroying it", g.Locked)
+	}
+
+	// With the lock gone, shots go back to the crosshair
+	g.Crosshair.Shoot(g)
+	if g.Crosshair.ShootingAt != other.Center || !other.Explosion.Exploding {
+		t.Errorf("shot after the lock cleared went to %v, want the crosshair at %v", g.Crosshair.ShootingAt, other.Center)
+	}
+
+	// A lock on an asteroid outside the turret arc can't pull shots round to it
+	defer func(on bool, degrees float64) { TurretArc, ArcDegrees = on, degrees }(TurretArc, ArcDegrees)
+	TurretArc, ArcDegrees = true, 90
+	g.Restart()
+	a, other = g.Asteroids[0], g.Asteroids[1]
+	a.Angle, other.Angle = g.Rotation+math.Pi, g.Rotation
+	a.Distance, other.Distance = 300, 300
+	a.Update(g)
+	other.Update(g)
+	g.Crosshair.Center = a.Center
+	g.Lock()
+	g.Crosshair.Center = other.Center
+	g.Crosshair.Shoot(g)
+	if g.Crosshair.ShootingAt != other.Center || a.Explosion.Exploding {
+		t.Errorf("shot went to the locked asteroid at %v outside the turret arc", a.Center)
+	}
+}
+
+func TestNearMiss(t *testing.T) {
+	defer func(reaction string, margin float64) { NearMiss, NearMissMargin = reaction, margin }(NearMiss, NearMissMargin)
+	NearMissMargin = 20
+
+	for _, reaction := range []string{"off", "split", "speed"} {
+		NearMiss = reaction
+		g, err := NewGame(1280, 960, assetLoader{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Sounds = &Sounds{}
+		g.Wave = 1
+		g.HowMany = 2
+		g.Restart()
+		close, clean := g.Asteroids[0], g.Asteroids[1]
+		close.Speed, clean.Speed = 1, 1
+		c := g.Crosshair
+		c.Center = image.Pt(400, 400)
+		close.Center = c.Center.Add(image.Pt(int(c.Radius+close.Radius)+10, 0))
+		clean.Center = c.Center.Add(image.Pt(0, int(c.Radius+clean.Radius)+40))
+
+		c.Shoot(g)
+		if !c.Missing {
+			t.Fatalf("%s: shot hit something", reaction)
+		}
+		wantCount, wantSpeed := 2, 1.0
+		switch reaction {
+		case "split":
+			wantCount = 3
+		case "speed":
+			wantSpeed = NearMissBoost
+		}
+		if g.Count != wantCount || len(g.Asteroids) != wantCount {
+			t.Errorf("%s: %d asteroids after a near miss, want %d", reaction, g.Count, wantCount)
+		}
+		if close.Speed != wantSpeed {
+			t.Errorf("%s: nearly hit asteroid at speed %v, want %v", reaction, close.Speed, wantSpeed)
+		}
+		if clean.Speed != 1 {
+			t.Errorf("%s: cleanly missed asteroid sped up to %v", reaction, clean.Speed)
+		}
+		if reaction == "split" {
+			if b := g.Asteroids[2]; b.Distance != close.Distance || b.Angle == close.Angle {
+				t.Errorf("split asteroid at %v, %v, want alongside %v, %v", b.Angle, b.Distance, close.Angle, close.Distance)
+			}
+		}
+	}
+}
+
+func TestNearMissSpeedLimit(t *testing.T) {
+	defer func(reaction string, boost float64) { NearMiss, NearMissBoost = reaction, boost }(NearMiss, NearMissBoost)
+	NearMiss, NearMissBoost = "speed", 2
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 1
+	g.HowMany = 1
+	g.Restart()
+	a := g.Asteroids[0]
+	a.Distance = 30
+	a.Speed = maxApproachSpeed(a.Distance)
+	g.nearMiss(a)
+	if a.Speed > maxApproachSpeed(a.Distance) {
+		t.Errorf("near miss sped an asteroid up to %v, past the limit of %v", a.Speed, maxApproachSpeed(a.Distance))
+	}
+}
+
+func TestDifficultyProfile(t *testing.T) {
+	base := currentTunables()
+	defer func(p Profile, name string) {
+		p.apply()
+		SaveFileName = name
+		base.restore()
+	}(currentProfile(), SaveFileName)
+
+	SaveFileName = filepath.Join(t.TempDir(), "lunar-defence.save")
+	if err := (&SaveFile{Difficulty: "fierce"}).Write(SaveFileName); err != nil {
+		t.Fatal(err)
+	}
+	fierce, _ := profileNamed("fierce")
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	if err := g.State.Transition(StatePlaying); err != nil {
+		t.Fatal(err)
+	}
+	if g.HowMany != fierce.HowManyStart || len(g.Asteroids) != fierce.HowManyStart {
+		t.Errorf("new game has %d asteroids, want the profile's %d", len(g.Asteroids), fierce.HowManyStart)
+	}
+	if WaveMultiplier != fierce.WaveMultiplier || DistanceVariance != fierce.DistanceVariance {
+		t.Errorf("profile's wave settings weren't applied")
+	}
+	for _, v := range g.Asteroids {
+		if v.Speed != fierce.AsteroidSpeed {
+			t.Fatalf("asteroid falling at %v, want the profile's %v", v.Speed, fierce.AsteroidSpeed)
+		}
+	}
+
+	// The preview plays on its own, without changing the real game
+	asteroids, count := len(g.Asteroids), g.Count
+	gentle := profiles[0]
+	g.Preview.Reset(g, 0)
+	if n := len(g.Preview.game.Asteroids); n != gentle.HowManyStart {
+		t.Fatalf("preview of %s starts with %d asteroids, want %d", gentle.ID, n, gentle.HowManyStart)
+	}
+	for _, v := range g.Preview.game.Asteroids {
+		if v.Speed > gentle.AsteroidSpeed {
+			t.Fatalf("preview asteroid falling at %v, faster than the profile's %v", v.Speed, gentle.AsteroidSpeed)
+		}
+	}
+	landed := false
+	for i := 0; i < 3000; i++ {
+		g.Preview.Update()
+		landed = landed || len(g.Preview.game.Asteroids) < gentle.HowManyStart
+	}
+	if !landed || len(g.Preview.game.Asteroids) == 0 {
+		t.Errorf("preview of %s didn't send in more asteroids as they landed", gentle.ID)
+	}
+	if currentProfile() != (Profile{
+		HowManyStart:     fierce.HowManyStart,
+		WaveMultiplier:   fierce.WaveMultiplier,
+		AsteroidSpeed:    fierce.AsteroidSpeed,
+		DistanceVariance: fierce.DistanceVariance,
+		SpawnGap:         fierce.SpawnGap,
+		FuseChance:       fierce.FuseChance,
+	}) || g.HowMany != fierce.HowManyStart || g.Count != count || len(g.Asteroids) != asteroids {
+		t.Errorf("previewing another profile changed the game")
+	}
+}
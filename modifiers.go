@@ -0,0 +1,210 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/font"
+)
+
+// modifierChoices is how many modifiers are offered between waves
+const modifierChoices = 3
+
+// A Modifier changes the rules for the rest of a run when it's chosen
+type Modifier struct {
+	ID    string
+	Name  string
+	Apply func(*Game)
+}
+
+// modifiers are all the modifiers that can be offered between waves
+var modifiers = []Modifier{
+	{"spin", "EVERYTHING SPINS FASTER", func(g *Game) {
+		RotationSpeed *= 1.25
+		MoonOrbitSpeed *= 1.25
+	}},
+	{"slingshot", "THE MOON SLINGS ASTEROIDS", func(g *Game) {
+		GravityAssist = true
+	}},
+	{"crowded", "ASTEROIDS COME CLOSER TOGETHER", func(g *Game) {
+		DistanceVariance *= 2
+	}},
+	{"rewind", "ONE MORE REWIND", func(g *Game) {
+		Rewinds++
+		g.Rewinds++
+	}},
+	{"breather", "LONGER BREAKS", func(g *Game) {
+		TimeBetweenWaves += 2
+	}},
+	{"shield", "THE MOON REACHES FURTHER", func(g *Game) {
+		Easy = true
+	}},
+	{"arc", "THE TURRET ONLY FIRES AHEAD", func(g *Game) {
+		TurretArc = true
+	}},
+	{"gravity", "THE MOON PULLS ASTEROIDS IN", func(g *Game) {
+		MoonGravity = true
+	}},
+	{"eclipse", "ECLIPSES HIDE YOUR AIDS", func(g *Game) {
+		EclipseBlind = true
+	}},
+}
+
+// Tunables are the settings modifiers can change, kept so each run can start
+// again from how they were set
+type Tunables struct {
+	RotationSpeed    float64
+	MoonOrbitSpeed   float64
+	GravityAssist    bool
+	DistanceVariance float64
+	Rewinds          int
+	TimeBetweenWaves int
+	Easy             bool
+	TurretArc        bool
+	MoonGravity      bool
+	EclipseBlind     bool
+}
+
+// currentTunables copies the settings that modifiers can change
+func currentTunables() Tunables {
+	return Tunables{
+		RotationSpeed:    RotationSpeed,
+		MoonOrbitSpeed:   MoonOrbitSpeed,
+		GravityAssist:    GravityAssist,
+		DistanceVariance: DistanceVariance,
+		Rewinds:          Rewinds,
+		TimeBetweenWaves: TimeBetweenWaves,
+		Easy:             Easy,
+		TurretArc:        TurretArc,
+		MoonGravity:      MoonGravity,
+		EclipseBlind:     EclipseBlind,
+	}
+}
+
+// restore sets the settings that modifiers can change back to t
+func (t Tunables) restore() {
+	RotationSpeed = t.RotationSpeed
+	MoonOrbitSpeed = t.MoonOrbitSpeed
+	GravityAssist = t.GravityAssist
+	DistanceVariance = t.DistanceVariance
+	Rewinds = t.Rewinds
+	TimeBetweenWaves = t.TimeBetweenWaves
+	Easy = t.Easy
+	TurretArc = t.TurretArc
+	MoonGravity = t.MoonGravity
+	EclipseBlind = t.EclipseBlind
+}
+
+// offerModifiers picks modifiers the run doesn't have yet to choose between
+func (g *Game) offerModifiers() {
+	var left []Modifier
+	for _, m := range modifiers {
+		if !g.HasModifier(m.ID) {
+			left = append(left, m)
+		}
+	}
+	g.Rand.Shuffle(len(left), func(i, j int) { left[i], left[j] = left[j], left[i] })
+	if len(left) > modifierChoices {
+		left = left[:modifierChoices]
+	}
+	g.Offer = left
+	g.Choosing = OfferMenu{}
+}
+
+// HasModifier reports whether the modifier with ID id is on for this run
+func (g *Game) HasModifier(id string) bool {
+	for _, m := range g.Modifiers {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ChooseModifier turns on the ith offered modifier for the rest of the run
+func (g *Game) ChooseModifier(i int) {
+	if i < 0 || i >= len(g.Offer) {
+		return
+	}
+	m := g.Offer[i]
+	log.Printf("modifier chosen: %s\n", m.Name)
+	m.Apply(g)
+	g.Modifiers = append(g.Modifiers, m)
+	g.Offer = nil
+}
+
+// An OfferMenu is which of the modifiers on offer is picked, moved through
+// with the stick, arrow keys or mouse wheel
+type OfferMenu struct {
+	Choice int
+	Held   bool // the stick is still pushed from the last move
+}
+
+// updateOffer chooses a modifier with the number keys, or by moving through
+// the offer and confirming with a click or the first gamepad button
+func (g *Game) updateOffer() {
+	if i := chooseModifierKey(len(g.Offer)); i >= 0 {
+		g.ChooseModifier(i)
+		return
+	}
+	m := &g.Choosing
+	_, dy := aimDirection()
+	_, wheel := ebiten.Wheel()
+	step := sign(wheel)
+	if !m.Held {
+		step += sign(dy)
+	}
+	m.Held = dy != 0
+	m.Choice = cycleChoice(m.Choice, step, len(g.Offer))
+	if clicked() {
+		g.ChooseModifier(m.Choice)
+	}
+}
+
+// cycleChoice moves choice on by step through n choices, wrapping round at
+// either end
+func cycleChoice(choice, step, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return ((choice+step)%n + n) % n
+}
+
+// sign is -1, 0 or 1 for negative, zero or positive x
+func sign(x float64) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// chooseModifierKey reads which offered modifier the number keys pick, or -1
+func chooseModifierKey(offered int) int {
+	for i := 0; i < offered; i++ {
+		if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// drawModifiers lists the modifiers on offer, with the picked one in brackets
+func drawModifiers(screen *ebiten.Image, offer []Modifier, choice int, face font.Face, width, height int) {
+	lines := make([]string, len(offer))
+	for i, m := range offer {
+		lines[i] = strconv.Itoa(i+1) + ": " + m.Name
+		if i == choice {
+			lines[i] = "[" + lines[i] + "]"
+		}
+	}
+	drawPanel(screen, face, width, height, "CHOOSE A MODIFIER", lines)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestModifiers(t *testing.T) {
+	base := currentTunables()
+	defer base.restore()
+
+	changed := map[string]func(before Tunables, g *Game) bool{
+		"spin": func(b Tunables, g *Game) bool {
+			return RotationSpeed > b.RotationSpeed && MoonOrbitSpeed > b.MoonOrbitSpeed
+		},
+		"slingshot": func(b Tunables, g *Game) bool { return GravityAssist },
+		"crowded":   func(b Tunables, g *Game) bool { return DistanceVariance > b.DistanceVariance },
+		"rewind":    func(b Tunables, g *Game) bool { return Rewinds == b.Rewinds+1 && g.Rewinds == b.Rewinds+1 },
+		"breather":  func(b Tunables, g *Game) bool { return TimeBetweenWaves > b.TimeBetweenWaves },
+		"shield":    func(b Tunables, g *Game) bool { return Easy },
+		"arc":       func(b Tunables, g *Game) bool { return TurretArc },
+		"gravity":   func(b Tunables, g *Game) bool { return MoonGravity },
+		"eclipse":   func(b Tunables, g *Game) bool { return EclipseBlind },
+	}
+	for _, m := range modifiers {
+		base.restore()
+		GravityAssist, Easy, TurretArc, MoonGravity, EclipseBlind = false, false, false, false, false
+		before := currentTunables()
+		g := &Game{Rewinds: Rewinds}
+		m.Apply(g)
+		check, ok := changed[m.ID]
+		if !ok {
+			t.Errorf("no test for modifier %s", m.ID)
+			continue
+		}
+		if !check(before, g) {
+			t.Errorf("modifier %s didn't change its tunable", m.ID)
+		}
+	}
+}
+
+func TestModifiersLastTheRun(t *testing.T) {
+	base := currentTunables()
+	defer base.restore()
+
+	g := &Game{Rand: rand.New(rand.NewSource(1)), Base: base}
+	g.offerModifiers()
+	if len(g.Offer) != modifierChoices {
+		t.Fatalf("offered %d modifiers, want %d", len(g.Offer), modifierChoices)
+	}
+	chosen := g.Offer[1]
+	g.ChooseModifier(1)
+	if len(g.Offer) != 0 || !g.HasModifier(chosen.ID) {
+		t.Fatalf("choosing didn't turn on %s", chosen.ID)
+	}
+	for i := 0; i < 10; i++ {
+		g.offerModifiers()
+		for _, m := range g.Offer {
+			if m.ID == chosen.ID {
+				t.Fatalf("offered %s again after choosing it", m.ID)
+			}
+		}
+	}
+
+	g.NewRun()
+	if g.HasModifier(chosen.ID) || currentTunables() != base {
+		t.Errorf("modifiers carried over into a new run")
+	}
+}
+
+func TestCycleChoice(t *testing.T) {
+	for _, c := range []struct{ choice, step, want int }{
+		{0, 1, 1},
+		{2, 1, 0},
+		{0, -1, 2},
+		{1, 0, 1},
+	} {
+		if got := cycleChoice(c.choice, c.step, 3); got != c.want {
+			t.Errorf("moving %d from %d gave %d, want %d", c.step, c.choice, got, c.want)
+		}
+	}
+}
+
+func TestTurretArc(t *testing.T) {
+	defer func(on bool, degrees float64) { TurretArc, ArcDegrees = on, degrees }(TurretArc, ArcDegrees)
+	TurretArc, ArcDegrees = true, 90
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(bearing float64) image.Point {
+		return g.Earth.Center.Add(image.Pt(int(300*math.Cos(bearing)), int(300*math.Sin(bearing))))
+	}
+
+	tests := []struct {
+		rotation, bearing float64
+		want              bool
+	}{
+		{0, 0, true},
+		{0, math.Pi / 5, true},
+		{0, -math.Pi / 5, true},
+		{0, math.Pi / 3, false},
+		{0, math.Pi, false},
+		{math.Pi / 2, math.Pi / 2, true},
+		{math.Pi / 2, 0, false},
+		{-7 * math.Pi / 4, math.Pi / 4, true}, // wound round more than once
+	}
+	for _, tt := range tests {
+		g.Rotation = tt.rotation
+		if got := g.InTurretArc(at(tt.bearing)); got != tt.want {
+			t.Errorf("earth turned %.2f, firing at %.2f: in arc %v, want %v", tt.rotation, tt.bearing, got, tt.want)
+		}
+	}
+
+	TurretArc = false
+	g.Rotation = 0
+	if !g.InTurretArc(at(math.Pi)) {
+		t.Errorf("firing blocked with the turret arc off")
+	}
+}
+
+func TestEclipse(t *testing.T) {
+	defer func(sun float64, blind bool) { SunAngle, EclipseBlind = sun, blind }(SunAngle, EclipseBlind)
+	SunAngle = 90
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	width := math.Asin(g.Moon.Radius / g.Moon.OrbitRadius(g))
+	turn := func(bearing float64) {
+		g.Moon.OrbitAngle = bearing
+	}
+
+	tests := []struct {
+		name    string
+		bearing float64
+		want    bool
+	}{
+		{"moon in front of the sun", math.Pi / 2, true},
+		{"just coming in", math.Pi/2 - width*0.9, true},
+		{"just going out", math.Pi/2 + width*0.9, true},
+		{"round a whole orbit", math.Pi/2 + 2*math.Pi, true},
+		{"not there yet", math.Pi/2 - width*1.5, false},
+		{"behind the Earth", -math.Pi / 2, false},
+		{"to the side", 0, false},
+	}
+	for _, tt := range tests {
+		turn(tt.bearing)
+		if got := g.Eclipsed(); got != tt.want {
+			t.Errorf("%s: eclipsed %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	turn(math.Pi / 2)
+	EclipseBlind = false
+	if g.aidsHidden() {
+		t.Errorf("eclipse hid the aids without the modifier")
+	}
+	EclipseBlind = true
+	if !g.aidsHidden() {
+		t.Errorf("eclipse didn't hide the aids with the modifier")
+	}
+
+	g.Moon = nil
+	if g.Eclipsed() {
+		t.Errorf("eclipse without a moon")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"math"
+)
+
+// NearMisses are the asteroids a shot at the crosshair just missed, close
+// enough to be within NearMissMargin of it without being hit
+func (o *Crosshair) NearMisses(as Asteroids) []*Asteroid {
+	var near []*Asteroid
+	for _, v := range as {
+		if !v.Alive || v.Explosion.Exploding {
+			continue
+		}
+		diff := o.Center.Sub(v.Center)
+		d := math.Hypot(float64(diff.X), float64(diff.Y)) - o.Radius - v.Radius
+		if d > 0 && d <= NearMissMargin {
+			near = append(near, v)
+		}
+	}
+	return near
+}
+
+// nearMiss has an asteroid a shot just missed react the way NearMiss says,
+// splitting into two or speeding up by NearMissBoost, though never so much it
+// could reach the Earth in less than MinVisibleTime
+func (g *Game) nearMiss(a *Asteroid) {
+	switch NearMiss {
+	case "split":
+		g.Spawn(1)
+		b := g.Asteroids[len(g.Asteroids)-1]
+		b.Angle = a.Angle + 2*a.Radius/(a.Distance+g.Earth.Radius) // just alongside
+		b.Distance, b.Speed, b.Health = a.Distance, a.Speed, a.Health
+		b.Seen, b.SeenTick = a.Seen, a.SeenTick
+		log.Printf("near miss split asteroid %d\n", a.ID)
+	case "speed":
+		a.Speed = math.Min(a.Speed*NearMissBoost, maxApproachSpeed(a.Distance))
+		log.Printf("near miss sped up asteroid %d\n", a.ID)
+	}
+}
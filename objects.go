@@ -7,7 +7,6 @@ package main
 import (
 	"image"
 	"image/color"
-	"image/png"
 	"log"
 	"math"
 	"time"
@@ -37,7 +36,10 @@ func (o *Object) Overlaps(p *Object) bool {
 
 // NewObject makes a new game Object with fields calculated from the input image
 func NewObject(filename string) *Object {
-	img := loadImage(filename)
+	img, err := assetLoader{}.Load(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return NewObjectFromImage(img)
 }
 
@@ -46,22 +48,69 @@ func NewObject(filename string) *Object {
 func NewObjectFromImage(img *ebiten.Image) *Object {
 	return &Object{
 		Image:  img,
-		Op:     &ebiten.DrawImageOptions{},
+		Op:     &ebiten.DrawImageOptions{Filter: spriteFilter()},
 		Center: image.Pt(0, 0),
 		Radius: float64(img.Bounds().Dx()) / 2,
 	}
 }
 
+// spriteFilter is the filter for scaling sprites, chosen by the Filter setting
+func spriteFilter() ebiten.Filter {
+	if Filter == "linear" {
+		return ebiten.FilterLinear
+	}
+	return ebiten.FilterNearest
+}
+
 // Moon is our moon, orbiting around the earth
 type Moon struct {
 	*Object
 	*Turret
+	OrbitAngle float64 // bearing from the Earth, moved on by MoonOrbitSpeed each tick
+}
+
+// Bearing is the angle from the Earth the moon is currently at
+func (o *Moon) Bearing() float64 {
+	return o.OrbitAngle
+}
+
+// moonAngle is the moon's bearing from the Earth, or 0 without the moon
+func (g *Game) moonAngle() float64 {
+	if g.Moon == nil {
+		return 0
+	}
+	return g.Moon.Bearing()
+}
+
+// OrbitRadius is how far the moon's centre is from the Earth's
+func (o *Moon) OrbitRadius(g *Game) float64 {
+	return g.Earth.Radius + o.Radius*MoonOrbitDistance
+}
+
+// Update moves the moon on round its orbit, at its own speed whatever the
+// global rotation is doing
+func (o *Moon) Update(g *Game) {
+	o.OrbitAngle -= capSpeed(MoonOrbitSpeed, MaxRotationSpeed)
+	o.place(g)
+
+	for _, v := range g.Asteroids {
+		blocked := o.Blocks(v)
+		studyCheck(g.Tick, "moon", v, o.Center, o.Radius, blocked)
+		if blocked && v.Alive && !v.Explosion.Exploding {
+			v.Explosion.Exploding = true
+			play(g.Sounds.ExplsnHi)
+			g.Count--
+			g.Score += scoreFor(v, g)
+			g.Stats.MoonKills++
+			g.chainSpawn()
+		}
+	}
 }
 
-// Update recalculates moon position
-func (o Moon) Update(g *Game) {
-	t := g.Rotation / MoonOrbitRatio
-	d := g.Earth.Radius + o.Radius*MoonOrbitDistance
+// place puts the moon and its turret where its orbit angle says it is
+func (o *Moon) place(g *Game) {
+	t := o.Bearing()
+	d := o.OrbitRadius(g)
 
 	// Calculated centre for collision detection
 	x := (d) * math.Cos(t)
@@ -80,16 +129,69 @@ func (o Moon) Update(g *Game) {
 	o.Op.GeoM.Translate(float64(o.Center.X), float64(o.Center.Y))
 	o.Op.GeoM.Translate(-o.Radius, -o.Radius)
 
-	for _, v := range g.Asteroids {
-		if o.Overlaps(v.Object) && v.Alive && !v.Explosion.Exploding {
-			v.Explosion.Exploding = true
-			g.Sounds.ExplsnHi.Rewind()
-			g.Sounds.ExplsnHi.Play()
-			g.Count--
-		}
+	o.Turret.Update(g)
+}
+
+// Blocks reports whether the moon is close enough to an asteroid to destroy
+// it, which in easy mode is further than just touching it
+func (o *Moon) Blocks(a *Asteroid) bool {
+	return o.distanceTo(a) <= o.reach(a)
+}
+
+// InCoverage reports whether an asteroid is inside the coverage drawn round
+// the moon, moonCoverage times its blocking reach, which isn't the same as
+// being blocked by it
+func (o *Moon) InCoverage(a *Asteroid) bool {
+	return o.distanceTo(a) <= o.reach(a)*moonCoverage
+}
+
+// moonCoverage is how many times its blocking reach the moon's coverage is
+// drawn out to
+const moonCoverage = 4
+
+// reach is how close the centres of the moon and an asteroid are when the
+// moon blocks it
+func (o *Moon) reach(a *Asteroid) float64 {
+	reach := o.Radius
+	if Easy {
+		reach *= EasyMoonReach
 	}
+	return reach + a.Radius
+}
 
-	o.Turret.Update(g)
+// Slingshot is how far an asteroid gets pulled around the Earth by passing
+// close to the moon, dragged along the way the moon's going and harder the
+// closer it gets
+func (o *Moon) Slingshot(a *Asteroid) float64 {
+	reach := o.reach(a) * GravityReach
+	d := o.distanceTo(a)
+	if d >= reach {
+		return 0
+	}
+	pull := GravityStrength * (1 - d/reach)
+	if MoonOrbitSpeed > 0 {
+		return -pull // the moon's bearing goes down as the rotation does
+	}
+	return pull
+}
+
+// Pull is how much the moon's own gravity speeds an asteroid up towards it
+// this tick, in pixels per tick along x and y, harder the closer it is
+func (o *Moon) Pull(a *Asteroid) (float64, float64) {
+	reach := o.reach(a) * GravityReach
+	d := o.distanceTo(a)
+	if d >= reach || d == 0 {
+		return 0, 0
+	}
+	pull := MoonPull * (1 - d/reach)
+	diff := o.Center.Sub(a.Center)
+	return pull * float64(diff.X) / d, pull * float64(diff.Y) / d
+}
+
+// distanceTo is how far apart the centres of the moon and an asteroid are
+func (o *Moon) distanceTo(a *Asteroid) float64 {
+	diff := o.Center.Sub(a.Center)
+	return math.Hypot(float64(diff.X), float64(diff.Y))
 }
 
 // Draw renders a Moon to the screen
@@ -135,12 +237,26 @@ func (o *Turret) Draw(screen *ebiten.Image) {
 // Earth is the earth, our home planet
 type Earth struct {
 	*Object
-	Center   image.Point
-	Impacted bool
+	Center      image.Point
+	Impacted    bool
+	WobbleAngle float64 // direction the Earth recoils in after a hit
+	WobbleTicks int     // how long the Earth has been wobbling for, -1 when still
+	Healing     int     // ticks left of the glow after getting a rewind back
 }
 
+// healGlowTicks is how long the Earth glows for when it's healed
+var healGlowTicks = ebiten.MaxTPS() / 2
+
 // Update repositions Earth
-func (o Earth) Update(g *Game) {
+func (o *Earth) Update(g *Game) {
+	wx, wy := o.WobbleOffset()
+	if o.WobbleTicks >= 0 {
+		o.WobbleTicks++
+		if wx == 0 && wy == 0 && o.WobbleTicks > 1 {
+			o.WobbleTicks = -1
+		}
+	}
+
 	o.Op.GeoM.Reset()
 	o.Op.GeoM.Translate(
 		-o.Radius,
@@ -148,6 +264,39 @@ func (o Earth) Update(g *Game) {
 	)
 	o.Op.GeoM.Rotate(g.Rotation)
 	o.Op.GeoM.Translate(o.Pt())
+	o.Op.GeoM.Translate(wx, wy)
+
+	// Glow green for a moment after healing
+	o.Op.ColorM.Reset()
+	if o.Healing > 0 {
+		o.Healing--
+		f := 0.4 * float64(o.Healing) / float64(healGlowTicks)
+		o.Op.ColorM.Translate(0, f, 0, 0)
+	}
+}
+
+// Wobble starts the Earth recoiling away from a hit coming from angle
+func (o *Earth) Wobble(angle float64) {
+	if ReducedMotion {
+		return
+	}
+	o.WobbleAngle = angle + math.Pi
+	o.WobbleTicks = 0
+}
+
+// WobbleOffset is how far the Earth is currently knocked from its centre, a
+// small oscillation that dies away quickly until it's back to zero
+func (o *Earth) WobbleOffset() (x, y float64) {
+	const amplitude, decay, speed = 8.0, 10.0, 0.8
+	if o.WobbleTicks < 0 {
+		return 0, 0
+	}
+	t := float64(o.WobbleTicks)
+	d := amplitude * math.Exp(-t/decay) * math.Cos(t*speed)
+	if math.Abs(d) < 0.5 && t > decay {
+		return 0, 0
+	}
+	return d * math.Cos(o.WobbleAngle), d * math.Sin(o.WobbleAngle)
 }
 
 // Draw renders a Earth to the screen
@@ -170,20 +319,109 @@ type Asteroid struct {
 	Explosion *Explosion
 	Alive     bool
 	Impacting bool
+	Seen      bool    // has been on screen
+	SeenTick  int     // game tick when it came on screen
+	Spin      float64 // how far the asteroid has turned
+	Speed     float64 // how far it falls each tick
+	Captured  int     // ticks left held still by the tractor beam
+	Flash     int     // ticks left of the flash when it came on screen
+	ID        int     // unique to each asteroid, for telling them apart in logs
+	Health    int     // how many shots it takes to destroy
+	Fuse      int     // ticks left before it self-destructs once seen, 0 if it won't
+	VX, VY    float64 // drift picked up from the moon's pull, in pixels per tick
+	Burn      float64 // health burnt away by the atmosphere, short of a whole point
 }
 
+// entryFlashTicks is how long an asteroid flashes for when it comes on screen
+var entryFlashTicks = ebiten.MaxTPS() / 4
+
+// driftDamping is how much of its drift an asteroid keeps from one tick to the
+// next, and maxDrift the fastest it can drift, in pixels per tick
+const driftDamping, maxDrift = 0.98, 3
+
 // Update recalculates Asteroid position
 func (o *Asteroid) Update(g *Game) {
-	var RotationSpeed float64 = AsteroidSpinRatio
-
-	// Asteroid impacts earth
-	if o.Distance > 0 {
-		o.Distance = o.Distance - 1
+	// Asteroid impacts earth, unless it's held by the tractor beam
+	studyCheck(g.Tick, "earth", o, g.Earth.Center, g.Earth.Radius, o.Distance <= 0)
+	from, fall := o.Distance, 0.0
+	if o.Captured > 0 {
+		o.Captured--
+	} else if o.Distance > 0 {
+		fall = o.Speed * calmFactor(g.Stats.Ticks)
+		o.Distance = o.Distance - fall
 	} else if o.Alive {
 		o.Impacting = true
 		o.Explosion.Exploding = true
 	}
 
+	// Passing the moon can fling the asteroid round onto a new path
+	if GravityAssist && g.Moon != nil {
+		o.Angle += g.Moon.Slingshot(o)
+	}
+
+	// The moon's own gravity curves the asteroid's path towards it, the drift
+	// moving it across the polar grid it falls along. The drift dies away once
+	// out of the moon's reach, and never brings the asteroid in any faster than
+	// it could fall by itself.
+	if MoonGravity && g.Moon != nil && o.Alive && !o.Explosion.Exploding && o.Captured == 0 {
+		ax, ay := g.Moon.Pull(o)
+		o.VX, o.VY = o.VX+ax, o.VY+ay
+	}
+	if o.VX != 0 || o.VY != 0 {
+		o.VX, o.VY = o.VX*driftDamping, o.VY*driftDamping
+		if v := math.Hypot(o.VX, o.VY); v > maxDrift {
+			o.VX, o.VY = o.VX*maxDrift/v, o.VY*maxDrift/v
+		}
+		r := o.Distance + g.Earth.Radius
+		x, y := r*math.Cos(o.Angle)+o.VX, r*math.Sin(o.Angle)+o.VY
+		o.Angle = math.Atan2(y, x)
+		o.Distance = math.Hypot(x, y) - g.Earth.Radius
+		limit := math.Max(fall, maxApproachSpeed(from))
+		o.Distance = math.Max(o.Distance, from-limit)
+	}
+
+	o.locate(g)
+
+	// Warn the player when the asteroid first comes into view, from the side
+	// it's coming in on, and flash it to catch their eye
+	if o.Flash > 0 {
+		o.Flash--
+	}
+	if !o.Seen && o.Center.In(image.Rect(0, 0, g.Width, g.Height)) {
+		o.Seen = true
+		o.SeenTick = g.Tick
+		if !ReducedMotion {
+			o.Flash = entryFlashTicks
+		}
+		if g.Sounds != nil {
+			g.Sounds.Warn(float64(o.Center.X-g.Width/2) / float64(g.Width/2))
+		}
+	}
+
+	// The atmosphere burns away at the asteroid, harder the lower it gets
+	if o.Alive && !o.Explosion.Exploding && o.Distance > 0 && o.Distance < Atmosphere {
+		o.Burn += BurnRate / float64(ebiten.MaxTPS()) * (1 - o.Distance/Atmosphere)
+		for ; o.Burn >= 1 && o.Health > 0; o.Burn-- {
+			o.Health--
+		}
+		if o.Health <= 0 {
+			g.burnUp(o)
+		}
+	}
+
+	// A lit fuse burns down once the asteroid is on screen
+	if o.Fuse > 0 && o.Seen && o.Alive && !o.Explosion.Exploding {
+		o.Fuse--
+		if o.Fuse == 0 {
+			g.Burst(o)
+		}
+	}
+
+	o.animate(g)
+}
+
+// locate works out the asteroid's centre on screen from its angle and distance
+func (o *Asteroid) locate(g *Game) {
 	// Calculated centre for collision detection
 	t := o.Angle
 	d := o.Distance + g.Earth.Radius
@@ -193,13 +431,17 @@ func (o *Asteroid) Update(g *Game) {
 		int(x)+g.Width/2,
 		int(y)+g.Height/2,
 	)
+}
 
+// animate spins the asteroid's sprite into place and plays its explosion
+func (o *Asteroid) animate(g *Game) {
 	// Re-translate GeoM
 	o.Op.GeoM.Reset()
 
 	// Spin the asteroid
+	o.Spin -= capSpeed(RotationSpeed*AsteroidSpinRatio, MaxSpinSpeed)
 	o.Op.GeoM.Translate(-o.Radius, -o.Radius)
-	o.Op.GeoM.Rotate(g.Rotation * RotationSpeed)
+	o.Op.GeoM.Rotate(o.Spin)
 	o.Op.GeoM.Translate(o.Radius, o.Radius)
 
 	// Move to newly calculated x, y with image offset to center
@@ -213,10 +455,17 @@ func (o *Asteroid) Update(g *Game) {
 	}
 }
 
-// Draw renders a Asteroid to the screen
+// Draw renders a Asteroid to the screen, brightened while it's flashing
 func (o *Asteroid) Draw(screen *ebiten.Image) {
 	if o.Alive {
-		screen.DrawImage(o.Image, o.Op)
+		op := o.Op
+		if o.Flash > 0 {
+			cp := *o.Op // keeping its filter and composite mode
+			op = &cp
+			f := 0.8 * float64(o.Flash) / float64(entryFlashTicks)
+			op.ColorM.Translate(f, f, f, 0)
+		}
+		screen.DrawImage(o.Image, op)
 		o.Explosion.Draw(screen)
 	}
 }
@@ -252,6 +501,17 @@ func (as Asteroids) Alive() bool {
 	return false
 }
 
+// burnUp destroys an asteroid that disintegrated in the atmosphere, worth
+// BurnScore points since the player didn't shoot it
+func (g *Game) burnUp(a *Asteroid) {
+	a.Explosion.Exploding = true
+	play(g.Sounds.ExplsnHi)
+	g.Count--
+	g.Score += BurnScore
+	g.chainSpawn()
+	log.Printf("asteroid %d burnt up in the atmosphere\n", a.ID)
+}
+
 // Impacting returns true if any Asteroids are impacting
 func (as Asteroids) Impacting() bool {
 	for _, v := range as {
@@ -262,14 +522,62 @@ func (as Asteroids) Impacting() bool {
 	return false
 }
 
+// LimitSpeed slows down any Asteroids that would otherwise reach the Earth in
+// less than MinVisibleTime from where they are now, so there's always time to
+// react however close they start
+func (as Asteroids) LimitSpeed() {
+	for _, v := range as {
+		v.Speed = math.Min(v.Speed, maxApproachSpeed(v.Distance))
+	}
+}
+
+// SetHealth makes each of the Asteroids take health shots to destroy
+func (as Asteroids) SetHealth(health int) {
+	for _, v := range as {
+		v.Health = health
+	}
+}
+
+// asteroidHealth is how many shots it takes to destroy an asteroid in a wave,
+// starting at AsteroidHealth and going up by one every HealthEvery waves, up to
+// MaxHealth
+func asteroidHealth(wave int) int {
+	health := AsteroidHealth
+	if HealthEvery > 0 && wave > 0 {
+		health += (wave - 1) / HealthEvery
+	}
+	if health > MaxHealth {
+		health = MaxHealth
+	}
+	if health < 1 {
+		health = 1
+	}
+	return health
+}
+
+// maxApproachSpeed is the fastest an asteroid distance away can go and still
+// take MinVisibleTime to reach the Earth
+func maxApproachSpeed(distance float64) float64 {
+	ticks := MinVisibleTime * float64(ebiten.MaxTPS())
+	if ticks <= 0 {
+		return math.Inf(1)
+	}
+	return distance / ticks
+}
+
 // An Explosion is an animated impact explosion
 type Explosion struct {
 	*Object
 	Frame     int
 	Exploding bool
 	Done      bool
+	Elapsed   float64 // seconds since it started exploding
 }
 
+// explosionFrames is how many frames the explosion animation has, each shown
+// for explosionFrameTime seconds whatever the tick rate
+const explosionFrames, explosionFrameTime = 7, 1.0 / 60
+
 // Update sets positioning and animation for Explosions
 func (o *Explosion) Update(g *Game, coords image.Point) {
 	o.Center.X, o.Center.Y = coords.X, coords.Y
@@ -278,14 +586,23 @@ func (o *Explosion) Update(g *Game, coords image.Point) {
 	o.Op.GeoM.Translate(-o.Radius, -o.Radius)
 
 	if o.Exploding {
-		if o.Frame < 7 {
-			o.Frame++
-		} else {
-			o.Frame = 1
-			o.Exploding = false
-			o.Done = true
-		}
+		o.advance(1 / float64(ebiten.MaxTPS()))
+	}
+}
+
+// advance moves the explosion animation on by dt seconds, finishing it once
+// every frame has had its time
+func (o *Explosion) advance(dt float64) {
+	const epsilon = 1e-9 // so adding up ticks lands exactly on frame boundaries
+	o.Elapsed += dt
+	if o.Elapsed+epsilon >= explosionFrames*explosionFrameTime {
+		o.Frame = 1
+		o.Elapsed = 0
+		o.Exploding = false
+		o.Done = true
+		return
 	}
+	o.Frame = 1 + int(o.Elapsed/explosionFrameTime+epsilon)
 }
 
 // Draw renders an Explosion to the screen
@@ -296,6 +613,9 @@ func (o *Explosion) Draw(screen *ebiten.Image) {
 			o.Frame*frameSize, 0, // top-left
 			(1+o.Frame)*frameSize, frameSize, // bottom-right
 		)).(*ebiten.Image), o.Op)
+		if Bloom && !ReducedMotion {
+			drawBloom(screen, o)
+		}
 	}
 }
 
@@ -306,44 +626,129 @@ type Crosshair struct {
 	Shooting     bool
 	Missing      bool
 	ShootingFrom image.Point
+	ShootingAt   image.Point // where the laser went, the locked asteroid if there is one
 	Explosion    *Explosion
+	X, Y         float64     // precise position, used for relative aiming
+	Cursor       image.Point // last known cursor position
+	Focusing     bool        // slowed down for precise aiming
+	Colour       color.RGBA  // unlocked cosmetic colour
+	Reticle      Reticle     // shape showing what clicking would do
+	Reticles     map[Reticle]*ebiten.Image
 }
 
 // Update recalculates the crosshair position
 func (o *Crosshair) Update(g *Game) {
 	o.Shooting = false
 	o.Missing = false
+	o.Focusing = focusing()
 
 	o.Op.GeoM.Reset()
-	o.Center = image.Pt(ebiten.CursorPosition())
+	cursor := g.cursorPosition()
+	if AimMode == "relative" {
+		speed := CrosshairSpeed
+		if o.Focusing {
+			speed *= FocusSensitivity
+		}
+		dx, dy := aimDirection()
+		bounds := image.Rect(0, 0, g.Width, g.Height)
+		o.X, o.Y = moveCrosshair(o.X, o.Y, dx, dy, speed, bounds)
+	} else {
+		o.X, o.Y = followCursor(o.X, o.Y, o.Cursor, cursor, o.Focusing)
+	}
+	o.Cursor = cursor
+	o.Center = image.Pt(int(o.X), int(o.Y))
+	if Debug && SnapToGrid {
+		o.Center = snapToGrid(o.Center, debugGridSize)
+	}
 	o.Op.GeoM.Translate(
 		float64(o.Center.X)-o.Radius,
 		float64(o.Center.Y)-o.Radius,
 	)
 
-	canShoot := !g.Breathless && !o.CoolingDown && !g.GameOver && g.Wave > 0
+	canShoot := !g.Breathless && !o.CoolingDown && !g.GameOver && g.Wave > 0 && !g.Radial.Open && g.InTurretArc(o.Center)
 	if canShoot && clicked() {
-		o.Missing = true
-		o.Shooting = true
-		o.ShootingFrom = g.Moon.Center
-		g.Sounds.Laser.Rewind()
-		g.Sounds.Laser.Play()
-		for _, v := range g.Asteroids {
-			if o.Overlaps(v.Object) && v.Alive && !v.Explosion.Exploding {
-				v.Explosion.Exploding = true
-				soundEffectDelay := time.NewTimer(time.Millisecond * 100)
-				go func() {
-					<-soundEffectDelay.C
-					g.Sounds.ExplsnMid.Rewind()
-					g.Sounds.ExplsnMid.Play()
-				}()
-				g.Count--
-				o.Missing = false
+		o.Shoot(g)
+	}
+	o.Reticle = reticleFor(o.CoolingDown, o.onTarget(g.Asteroids))
+
+	o.Explosion.Update(g, g.Gunpoint())
+}
+
+// Targets are the living asteroids a shot at the crosshair hits, either all of
+// the ones under it or with multi false only the one closest to its centre
+func (o *Crosshair) Targets(as Asteroids, multi bool, tick int) []*Asteroid {
+	var hits []*Asteroid
+	for _, v := range as {
+		overlaps := o.Overlaps(v.Object)
+		studyCheck(tick, "shot", v, o.Center, o.Radius, overlaps)
+		if overlaps && v.Alive && !v.Explosion.Exploding {
+			hits = append(hits, v)
+		}
+	}
+	if multi || len(hits) < 2 {
+		return hits
+	}
+	gap := func(v *Asteroid) float64 {
+		diff := o.Center.Sub(v.Center)
+		return math.Hypot(float64(diff.X), float64(diff.Y))
+	}
+	closest := hits[0]
+	for _, v := range hits[1:] {
+		if gap(v) < gap(closest) {
+			closest = v
+		}
+	}
+	return []*Asteroid{closest}
+}
+
+// Shoot fires a laser at the crosshair, destroying any asteroids there or
+// cooling down if it missed
+func (o *Crosshair) Shoot(g *Game) {
+	o.Missing = true
+	o.Shooting = true
+	o.ShootingFrom = g.Gunpoint()
+	g.Stats.Shots++
+	play(g.Sounds.Laser)
+	targets := o.Targets(g.Asteroids, MultiHit, g.Tick)
+	o.ShootingAt = o.Center
+	if locked := g.LockedTarget(); locked != nil && g.InTurretArc(locked.Center) {
+		targets = []*Asteroid{locked}
+		o.ShootingAt = locked.Center
+	}
+	destroyed := false
+	for _, v := range targets {
+		o.Missing = false
+		v.Health--
+		if v.Health > 0 {
+			if !ReducedMotion {
+				v.Flash = entryFlashTicks
 			}
+			continue // tough enough to take another hit
+		}
+		v.Explosion.Exploding = true
+		destroyed = true
+		soundEffectDelay := time.NewTimer(time.Millisecond * 100)
+		go func() {
+			<-soundEffectDelay.C
+			play(g.Sounds.ExplsnMid)
+		}()
+		g.Count--
+		g.Score += scoreFor(v, g)
+		g.Stats.Kills++
+		g.chainSpawn()
+		if Practice && v.Seen {
+			g.Practice.Record(g.Tick-v.SeenTick, v.Distance)
 		}
 	}
 
+	if destroyed {
+		g.Stats.Hits++
+	}
+
 	if o.Missing {
+		for _, v := range o.NearMisses(g.Asteroids) {
+			g.nearMiss(v)
+		}
 		o.CoolingDown = true
 		o.Explosion.Exploding = true
 		coolDownTimer := time.NewTimer(time.Second)
@@ -352,47 +757,113 @@ func (o *Crosshair) Update(g *Game) {
 			o.CoolingDown = false
 		}()
 	}
-
-	o.Explosion.Update(g, g.Moon.Center)
 }
 
 // Draw renders a Crosshair to the screen
 func (o *Crosshair) Draw(screen *ebiten.Image) {
-	screen.DrawImage(o.Image, o.Op)
+	img := o.Image
+	o.Op.ColorM.Reset()
+	if shape, ok := o.Reticles[o.Reticle]; ok {
+		img = shape
+	}
+	if o.Colour != (color.RGBA{}) {
+		o.Op.ColorM.Scale(float64(o.Colour.R)/255, float64(o.Colour.G)/255, float64(o.Colour.B)/255, 1)
+	}
+	screen.DrawImage(img, o.Op)
+
+	// Draw a faint smaller crosshair inside the normal one while focusing
+	if o.Focusing {
+		op := &ebiten.DrawImageOptions{Filter: spriteFilter()}
+		op.GeoM.Translate(-o.Radius, -o.Radius)
+		op.GeoM.Scale(0.5, 0.5)
+		op.GeoM.Translate(float64(o.Center.X), float64(o.Center.Y))
+		op.ColorM.Scale(1, 1, 1, 0.5)
+		screen.DrawImage(o.Image, op)
+	}
 	o.Explosion.Draw(screen)
 
-	// Draw laser from the moon to the crosshair
+	// Draw laser from the moon to what it was fired at
 	if o.Shooting {
 		ebitenutil.DrawLine(
 			screen,
 			float64(o.ShootingFrom.X),
 			float64(o.ShootingFrom.Y),
-			float64(o.Center.X),
-			float64(o.Center.Y),
+			float64(o.ShootingAt.X),
+			float64(o.ShootingAt.Y),
 			color.RGBA{255, 0, 0, 255},
 		)
 	}
 }
 
-// Shorthand for when the left mouse button has just been clicked
-func clicked() bool {
-	return inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+// moveCrosshair moves a crosshair at x, y in the direction dx, dy at the given
+// speed, keeping it inside bounds
+func moveCrosshair(x, y, dx, dy, speed float64, bounds image.Rectangle) (float64, float64) {
+	// Normalise so diagonals aren't faster than straight lines
+	if l := math.Hypot(dx, dy); l > 1 {
+		dx, dy = dx/l, dy/l
+	}
+	x = math.Max(float64(bounds.Min.X), math.Min(float64(bounds.Max.X), x+dx*speed))
+	y = math.Max(float64(bounds.Min.Y), math.Min(float64(bounds.Max.Y), y+dy*speed))
+	return x, y
 }
 
-// Load an image from embedded FS into an ebiten Image object
-func loadImage(name string) *ebiten.Image {
-	log.Printf("loading %s\n", name)
+// followCursor moves a crosshair at x, y as the cursor moves from last to
+// cursor, exactly following it normally but at a reduced rate while focusing
+func followCursor(x, y float64, last, cursor image.Point, focusing bool) (float64, float64) {
+	if !focusing {
+		return float64(cursor.X), float64(cursor.Y)
+	}
+	d := cursor.Sub(last)
+	return x + float64(d.X)*FocusSensitivity, y + float64(d.Y)*FocusSensitivity
+}
 
-	file, err := assets.Open(name)
-	if err != nil {
-		log.Fatalf("error opening file %s: %v\n", name, err)
+// aimDirection reads the direction the player wants to move the crosshair in
+// from the arrow keys and the left stick of any gamepad
+func aimDirection() (dx, dy float64) {
+	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		dx--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyRight) {
+		dx++
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyUp) {
+		dy--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyDown) {
+		dy++
 	}
-	defer file.Close()
 
-	raw, err := png.Decode(file)
-	if err != nil {
-		log.Fatalf("error decoding file %s as PNG: %v\n", name, err)
+	const deadZone = 0.2
+	for _, id := range ebiten.GamepadIDs() {
+		if x := ebiten.GamepadAxis(id, 0); math.Abs(x) > deadZone {
+			dx += x
+		}
+		if y := ebiten.GamepadAxis(id, 1); math.Abs(y) > deadZone {
+			dy += y
+		}
 	}
+	return dx, dy
+}
 
-	return ebiten.NewImageFromImage(raw)
+// Shorthand for when the focus key, focus mouse button or a gamepad shoulder
+// button is being held down
+func focusing() bool {
+	for _, id := range ebiten.GamepadIDs() {
+		if ebiten.IsGamepadButtonPressed(id, ebiten.GamepadButton4) {
+			return true
+		}
+	}
+	return ebiten.IsKeyPressed(controls.Keys[ActionFocus]) ||
+		ebiten.IsMouseButtonPressed(controls.Buttons[ActionFocus])
+}
+
+// Shorthand for when the shoot mouse button (or the first gamepad button) has
+// just been clicked
+func clicked() bool {
+	for _, id := range ebiten.GamepadIDs() {
+		if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton0) {
+			return true
+		}
+	}
+	return inpututil.IsMouseButtonJustPressed(controls.Buttons[ActionShoot])
 }
@@ -1,6 +1,16 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
 
 func TestOverlaps(t *testing.T) {
 	object := NewObject("assets/asteroid.png")
@@ -10,3 +20,636 @@ func TestOverlaps(t *testing.T) {
 	// TODO: write real collision test cases here, the above is just to stop the
 	// compiler complaining about unused vars etc
 }
+
+func TestMoveCrosshair(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	tests := []struct {
+		name         string
+		x, y, dx, dy float64
+		wantX, wantY float64
+	}{
+		{"still", 50, 50, 0, 0, 50, 50},
+		{"right", 50, 50, 1, 0, 60, 50},
+		{"up", 50, 50, 0, -1, 50, 40},
+		{"diagonal is normalised", 50, 50, 1, 1, 50 + 10/math.Sqrt2, 50 + 10/math.Sqrt2},
+		{"half stick is slower", 50, 50, 0.5, 0, 55, 50},
+		{"clamped left", 5, 50, -1, 0, 0, 50},
+		{"clamped bottom", 50, 95, 0, 1, 50, 100},
+	}
+	for _, tt := range tests {
+		x, y := moveCrosshair(tt.x, tt.y, tt.dx, tt.dy, 10, bounds)
+		if math.Abs(x-tt.wantX) > 1e-9 || math.Abs(y-tt.wantY) > 1e-9 {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", tt.name, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestMoonOrbitSpeed(t *testing.T) {
+	defer func(speed float64) { MoonOrbitSpeed = speed }(MoonOrbitSpeed)
+
+	for _, speed := range []float64{0.01, 0.05, -0.025} {
+		MoonOrbitSpeed = speed
+		g := &Game{
+			Width:     1280,
+			Height:    960,
+			Rotation:  -1.5,
+			Earth:     &Earth{Object: NewObject("assets/earth.png")},
+			Crosshair: &Crosshair{Object: NewObject("assets/crosshair.png")},
+		}
+		g.Moon = &Moon{
+			Object:     NewObject("assets/moon.png"),
+			Turret:     &Turret{Object: NewObject("assets/turret.png")},
+			OrbitAngle: 0.5,
+		}
+		g.Moon.Update(g)
+
+		d := g.Earth.Radius + g.Moon.Radius*MoonOrbitDistance
+		want := image.Pt(
+			int(d*math.Cos(0.5-speed))+g.Width/2,
+			int(d*math.Sin(0.5-speed))+g.Height/2,
+		)
+		if g.Moon.Center != want {
+			t.Errorf("speed %v: moon at %v, want %v", speed, g.Moon.Center, want)
+		}
+	}
+}
+
+func TestMoonOrbitIndependent(t *testing.T) {
+	defer func(rotation, moon float64) { RotationSpeed, MoonOrbitSpeed = rotation, moon }(RotationSpeed, MoonOrbitSpeed)
+	MoonOrbitSpeed = 0.01
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rotation := range []float64{0.02, 0.08, -0.05} {
+		RotationSpeed = rotation
+		g.Moon.OrbitAngle = 1
+		for i := 0; i < 10; i++ {
+			g.Rotation -= RotationSpeed
+			g.Moon.Update(g)
+		}
+		if got := g.Moon.Bearing(); math.Abs(got-0.9) > 1e-9 {
+			t.Errorf("earth spinning at %v moved the moon to %v, want 0.9", rotation, got)
+		}
+	}
+
+	// Spinning the whole world round doesn't move the moon either
+	g.Rotation += 2
+	if got := g.Moon.Bearing(); math.Abs(got-0.9) > 1e-9 {
+		t.Errorf("global rotation moved the moon to %v", got)
+	}
+}
+
+func TestFollowCursor(t *testing.T) {
+	last, cursor := image.Pt(100, 100), image.Pt(200, 150)
+
+	x, y := followCursor(100, 100, last, cursor, false)
+	if x != 200 || y != 150 {
+		t.Errorf("unfocused crosshair at (%v, %v), want it on the cursor", x, y)
+	}
+
+	x, y = followCursor(100, 100, last, cursor, true)
+	if x >= 200 || y >= 150 || x <= 100 || y <= 100 {
+		t.Errorf("focused crosshair at (%v, %v), want it between start and cursor", x, y)
+	}
+	if want := 100 + 100*FocusSensitivity; x != want {
+		t.Errorf("focused crosshair moved to x %v, want %v", x, want)
+	}
+}
+
+func TestMoveCrosshairFocused(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 1000)
+	normal, _ := moveCrosshair(500, 500, 1, 0, CrosshairSpeed, bounds)
+	focused, _ := moveCrosshair(500, 500, 1, 0, CrosshairSpeed*FocusSensitivity, bounds)
+	if focused-500 >= normal-500 {
+		t.Errorf("focused crosshair moved %v, normal %v", focused-500, normal-500)
+	}
+}
+
+func TestEarthWobbleDecays(t *testing.T) {
+	g := &Game{}
+	earth := &Earth{Object: NewObject("assets/earth.png"), WobbleTicks: -1}
+	if x, y := earth.WobbleOffset(); x != 0 || y != 0 {
+		t.Fatalf("still Earth is offset by (%v, %v)", x, y)
+	}
+
+	earth.Wobble(0)
+	if x, _ := earth.WobbleOffset(); x >= 0 {
+		t.Errorf("Earth hit from the right recoiled to x offset %v, want left", x)
+	}
+
+	for i := 0; i < 200; i++ {
+		earth.Update(g)
+	}
+	if x, y := earth.WobbleOffset(); x != 0 || y != 0 || earth.WobbleTicks != -1 {
+		t.Errorf("wobble didn't die down, offset (%v, %v) after %d ticks", x, y, earth.WobbleTicks)
+	}
+}
+
+func TestEarthWobbleReducedMotion(t *testing.T) {
+	ReducedMotion = true
+	defer func() { ReducedMotion = false }()
+
+	earth := &Earth{Object: NewObject("assets/earth.png"), WobbleTicks: -1}
+	earth.Wobble(0)
+	if x, y := earth.WobbleOffset(); x != 0 || y != 0 {
+		t.Errorf("Earth wobbled by (%v, %v) with reduced motion", x, y)
+	}
+}
+
+func TestSpriteFilter(t *testing.T) {
+	defer func(f string) { Filter = f }(Filter)
+	for setting, want := range map[string]ebiten.Filter{
+		"nearest": ebiten.FilterNearest,
+		"linear":  ebiten.FilterLinear,
+	} {
+		Filter = setting
+		object := NewObject("assets/asteroid.png")
+		if object.Op.Filter != want {
+			t.Errorf("filter %s gave sprite filter %v, want %v", setting, object.Op.Filter, want)
+		}
+	}
+}
+
+func TestWaveTint(t *testing.T) {
+	ramp, err := parseTints("ffffff ffeedd #ffddbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		wave int
+		want color.RGBA
+	}{
+		{0, color.RGBA{255, 255, 255, 255}},
+		{1, color.RGBA{255, 255, 255, 255}},
+		{2, color.RGBA{255, 238, 221, 255}},
+		{3, color.RGBA{255, 221, 187, 255}},
+		{10, color.RGBA{255, 221, 187, 255}},
+	} {
+		if got := waveTint(tt.wave, ramp); got != tt.want {
+			t.Errorf("waveTint(%d) = %v, want %v", tt.wave, got, tt.want)
+		}
+	}
+	if got := waveTint(5, nil); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("waveTint with no ramp = %v, want white", got)
+	}
+	if _, err := parseTints("ffffff red"); err == nil {
+		t.Errorf("parsed a bad tint")
+	}
+}
+
+func TestCameraScreenToWorld(t *testing.T) {
+	c := Camera{Active: true, Zoom: 1}
+	c.Pan(100, -50)
+	c.ZoomAt(300, 200, 2)
+
+	// Whatever was under the point zoomed at stays there
+	if x, y := c.ScreenToWorld(300, 200); math.Abs(x-200) > 1e-9 || math.Abs(y-250) > 1e-9 {
+		t.Errorf("zoomed point is at (%v, %v), want (200, 250)", x, y)
+	}
+
+	// And going back to the screen lands where it started
+	m := c.GeoM()
+	for _, p := range [][2]float64{{0, 0}, {640, 480}, {13, 370}} {
+		wx, wy := c.ScreenToWorld(p[0], p[1])
+		if sx, sy := m.Apply(wx, wy); math.Abs(sx-p[0]) > 1e-9 || math.Abs(sy-p[1]) > 1e-9 {
+			t.Errorf("(%v, %v) came back as (%v, %v)", p[0], p[1], sx, sy)
+		}
+	}
+
+	off := Camera{X: 100, Zoom: 3}
+	if x, y := off.ScreenToWorld(10, 20); x != 10 || y != 20 {
+		t.Errorf("inactive camera moved (10, 20) to (%v, %v)", x, y)
+	}
+}
+
+func TestSnapToGrid(t *testing.T) {
+	for _, tt := range []struct{ p, want image.Point }{
+		{image.Pt(0, 0), image.Pt(0, 0)},
+		{image.Pt(7, 9), image.Pt(0, 16)},
+		{image.Pt(24, 23), image.Pt(32, 16)},
+		{image.Pt(-9, 100), image.Pt(-16, 96)},
+	} {
+		if got := snapToGrid(tt.p, 16); got != tt.want {
+			t.Errorf("snapToGrid(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMoonBlocksEasy(t *testing.T) {
+	defer func(e bool) { Easy = e }(Easy)
+
+	moon := &Moon{Object: &Object{Radius: 20}}
+	moon.Center = image.Pt(100, 100)
+	near := &Asteroid{Object: &Object{Radius: 10}}
+	near.Center = image.Pt(125, 100)
+	far := &Asteroid{Object: &Object{Radius: 10}}
+	far.Center = image.Pt(145, 100)
+
+	Easy = false
+	if !moon.Blocks(near) || moon.Blocks(far) {
+		t.Errorf("normal moon blocks near %v and far %v, want only near", moon.Blocks(near), moon.Blocks(far))
+	}
+	Easy = true
+	if !moon.Blocks(far) {
+		t.Errorf("easy moon doesn't reach an asteroid %v away", far.Center.Sub(moon.Center))
+	}
+}
+
+func TestMoonInCoverage(t *testing.T) {
+	moon := &Moon{Object: &Object{Radius: 20}}
+	moon.Center = image.Pt(100, 100)
+	a := &Asteroid{Object: &Object{Radius: 10}}
+
+	a.Center = image.Pt(200, 100)
+	if moon.Blocks(a) || !moon.InCoverage(a) {
+		t.Errorf("asteroid 100 away blocked %v in coverage %v, want only in coverage", moon.Blocks(a), moon.InCoverage(a))
+	}
+	a.Center = image.Pt(100, 250)
+	if moon.InCoverage(a) {
+		t.Errorf("asteroid 150 away is in coverage")
+	}
+}
+
+func TestBloom(t *testing.T) {
+	img := glowImage(glowSize)
+	centre := img.RGBAAt(glowSize/2, glowSize/2).A
+	edge := img.RGBAAt(glowSize/2, glowSize/8).A
+	corner := img.RGBAAt(0, 0).A
+	if !(centre > edge && edge > corner) || corner != 0 {
+		t.Errorf("glow isn't brightest in the middle: centre %d, edge %d, corner %d", centre, edge, corner)
+	}
+
+	for frame := 2; frame <= 7; frame++ {
+		if bloomAlpha(frame) >= bloomAlpha(frame-1) {
+			t.Errorf("glow didn't fade from frame %d to %d", frame-1, frame)
+		}
+	}
+	if got := bloomAlpha(1); got != BloomIntensity {
+		t.Errorf("glow starts at %v, want BloomIntensity %v", got, BloomIntensity)
+	}
+}
+
+func TestMoonSlingshot(t *testing.T) {
+	defer func(speed float64) { MoonOrbitSpeed = speed }(MoonOrbitSpeed)
+	MoonOrbitSpeed = 0.01
+	moon := &Moon{Object: &Object{Radius: 20}}
+	moon.Center = image.Pt(100, 100)
+	near := &Asteroid{Object: &Object{Radius: 10}}
+	near.Center = image.Pt(130, 100)
+	nearer := &Asteroid{Object: &Object{Radius: 10}}
+	nearer.Center = image.Pt(110, 100)
+	far := &Asteroid{Object: &Object{Radius: 10}}
+	far.Center = image.Pt(300, 100)
+
+	if got := moon.Slingshot(far); got != 0 {
+		t.Errorf("far asteroid deflected by %v", got)
+	}
+	n, nn := moon.Slingshot(near), moon.Slingshot(nearer)
+	if n == 0 || math.Abs(nn) <= math.Abs(n) {
+		t.Errorf("deflections %v near and %v nearer, want more the closer it gets", n, nn)
+	}
+	if n > 0 {
+		t.Errorf("deflected by %v, want it dragged the way the moon's bearing goes", n)
+	}
+	MoonOrbitSpeed = -0.01
+	if got := moon.Slingshot(near); got != -n {
+		t.Errorf("reversed moon deflected by %v, want %v", got, -n)
+	}
+}
+
+func TestMoonGravity(t *testing.T) {
+	defer func(on bool) { MoonGravity = on }(MoonGravity)
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 1
+	g.HowMany = 2
+	g.Restart()
+	g.Moon.Update(g)
+	near, far := g.Asteroids[0], g.Asteroids[1]
+	for _, a := range []*Asteroid{near, far} {
+		a.Speed = 0
+		a.Angle = g.Moon.Bearing()
+	}
+	near.Distance = g.Moon.OrbitRadius(g) - g.Earth.Radius + 60 // just outside the moon
+	far.Distance = 2000
+
+	MoonGravity = false
+	near.Update(g)
+	if near.VX != 0 || near.VY != 0 {
+		t.Fatalf("asteroid pulled at %v, %v with moon gravity off", near.VX, near.VY)
+	}
+
+	MoonGravity = true
+	before := g.Moon.distanceTo(near)
+	near.Update(g)
+	far.Update(g)
+	toMoon := g.Moon.Center.Sub(near.Center)
+	if near.VX*float64(toMoon.X)+near.VY*float64(toMoon.Y) <= 0 {
+		t.Errorf("asteroid near the moon drifting %v, %v, not towards it at %v", near.VX, near.VY, toMoon)
+	}
+	for i := 0; i < 20; i++ {
+		near.Update(g)
+	}
+	if after := g.Moon.distanceTo(near); after >= before {
+		t.Errorf("asteroid went from %v to %v away from the moon", before, after)
+	}
+	if far.VX != 0 || far.VY != 0 {
+		t.Errorf("asteroid out of reach pulled at %v, %v", far.VX, far.VY)
+	}
+}
+
+func TestDriftLimits(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 1
+	g.HowMany = 1
+	g.Restart()
+	a := g.Asteroids[0]
+	a.Speed, a.Angle, a.Distance = 0, 0, 100
+	a.VX = -50 // straight at the Earth, far faster than anything could fall
+
+	a.Update(g)
+	if v := math.Hypot(a.VX, a.VY); v > maxDrift {
+		t.Errorf("drifting at %v, faster than %v", v, maxDrift)
+	}
+	if fell := 100 - a.Distance; fell > maxApproachSpeed(100)+1e-9 {
+		t.Errorf("drift brought the asteroid in %v in a tick, more than %v", fell, maxApproachSpeed(100))
+	}
+
+	// Flung outwards, it stops drifting before long instead of leaving forever
+	a.VX, a.Distance = maxDrift, 100
+	for i := 0; i < 10*ebiten.MaxTPS(); i++ {
+		a.Update(g)
+	}
+	if v := math.Hypot(a.VX, a.VY); v > 0.01 {
+		t.Errorf("still drifting at %v after ten seconds", v)
+	}
+}
+
+func TestAtmosphereBurn(t *testing.T) {
+	defer func(height, rate float64) { Atmosphere, BurnRate = height, rate }(Atmosphere, BurnRate)
+	Atmosphere, BurnRate = 80, 30
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 2
+	g.Restart()
+	weak, tough := g.Asteroids[0], g.Asteroids[1]
+	weak.Health, tough.Health = 1, 50
+	for _, a := range []*Asteroid{weak, tough} {
+		a.Distance, a.Speed = 100, 1
+	}
+
+	burntAt := -1.0
+	for i := 0; i < 100 && tough.Distance > 0; i++ {
+		weak.Update(g)
+		tough.Update(g)
+		if weak.Explosion.Exploding && burntAt < 0 {
+			burntAt = weak.Distance
+		}
+	}
+	if burntAt <= 0 || weak.Impacting {
+		t.Errorf("weak asteroid burnt up at %v, want before reaching the surface", burntAt)
+	}
+	if tough.Explosion.Exploding || tough.Health == 50 {
+		t.Errorf("tough asteroid burnt to %d health, want some lost but still there", tough.Health)
+	}
+	if g.Score != 0 || g.Count != 1 {
+		t.Errorf("score %d and %d left after burning up, want 0 and 1", g.Score, g.Count)
+	}
+
+	// Without a burn rate nothing burns
+	BurnRate = 0
+	g.Restart()
+	a := g.Asteroids[0]
+	a.Health, a.Distance, a.Speed = 1, 10, 0
+	for i := 0; i < 100; i++ {
+		a.Update(g)
+	}
+	if a.Explosion.Exploding || a.Burn != 0 {
+		t.Errorf("asteroid burnt with no burn rate")
+	}
+}
+
+func TestReticle(t *testing.T) {
+	for _, tt := range []struct {
+		coolingDown, onTarget bool
+		want                  Reticle
+	}{
+		{false, false, ReticleNeutral},
+		{false, true, ReticleReady},
+		{true, false, ReticleBlocked},
+		{true, true, ReticleBlocked},
+	} {
+		if got := reticleFor(tt.coolingDown, tt.onTarget); got != tt.want {
+			t.Errorf("reticleFor(%v, %v) = %v, want %v", tt.coolingDown, tt.onTarget, got, tt.want)
+		}
+	}
+
+	base := ebiten.NewImage(24, 24)
+	for r, img := range newReticles(base) {
+		if img.Bounds() != base.Bounds() {
+			t.Errorf("reticle %v is %v, want the same size as the crosshair %v", r, img.Bounds(), base.Bounds())
+		}
+	}
+}
+
+func TestLimitSpeed(t *testing.T) {
+	defer func(v float64) { MinVisibleTime = v }(MinVisibleTime)
+	MinVisibleTime = 2
+	ticks := MinVisibleTime * float64(ebiten.MaxTPS())
+
+	close := &Asteroid{Distance: 60, Speed: 1}
+	far := &Asteroid{Distance: 10000, Speed: 1}
+	Asteroids{close, far}.LimitSpeed()
+
+	if want := 60 / ticks; math.Abs(close.Speed-want) > 1e-9 {
+		t.Errorf("close asteroid speed %v, want %v", close.Speed, want)
+	}
+	if far.Speed != 1 {
+		t.Errorf("far asteroid slowed to %v", far.Speed)
+	}
+
+	// However close it starts it still takes the minimum time to arrive
+	n := 0
+	for close.Distance > 1e-9 {
+		close.Distance -= close.Speed
+		n++
+	}
+	if float64(n) < ticks-1 {
+		t.Errorf("close asteroid arrived in %d ticks, want at least %v", n, ticks)
+	}
+
+	MinVisibleTime = 0
+	fast := &Asteroid{Distance: 1, Speed: 5}
+	Asteroids{fast}.LimitSpeed()
+	if fast.Speed != 5 {
+		t.Errorf("speed limited to %v with no minimum time", fast.Speed)
+	}
+}
+
+func TestCrosshairTargets(t *testing.T) {
+	asteroid := func(x, y int) *Asteroid {
+		return &Asteroid{
+			Object:    &Object{Center: image.Pt(x, y), Radius: 10},
+			Explosion: &Explosion{},
+			Alive:     true,
+		}
+	}
+	c := &Crosshair{Object: &Object{Center: image.Pt(100, 100), Radius: 10}}
+	near := asteroid(104, 100)
+	further := asteroid(90, 108)
+	dead := asteroid(100, 100)
+	dead.Alive = false
+	away := asteroid(300, 300)
+	as := Asteroids{further, dead, near, away}
+
+	if hits := c.Targets(as, true, 0); len(hits) != 2 || hits[0] != further || hits[1] != near {
+		t.Errorf("multi-target hit %d asteroids, want the 2 living ones under the crosshair", len(hits))
+	}
+	if hits := c.Targets(as, false, 0); len(hits) != 1 || hits[0] != near {
+		t.Errorf("single-target hit %d asteroids, want only the closest", len(hits))
+	}
+	if hits := c.Targets(Asteroids{away}, false, 0); len(hits) != 0 {
+		t.Errorf("shot away from every asteroid hit %d", len(hits))
+	}
+}
+
+func TestEntryFlash(t *testing.T) {
+	defer func(r bool) { ReducedMotion = r }(ReducedMotion)
+
+	for _, reduced := range []bool{false, true} {
+		ReducedMotion = reduced
+		g, err := NewGame(1280, 960, assetLoader{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Wave = 1
+		g.HowMany = 1
+		g.Restart()
+		a := g.Asteroids[0]
+		a.Distance = 2000
+
+		a.Update(g)
+		if a.Seen || a.Flash != 0 {
+			t.Fatalf("asteroid flashed before coming on screen")
+		}
+
+		a.Distance = 100
+		a.Update(g)
+		want := entryFlashTicks
+		if reduced {
+			want = 0
+		}
+		if !a.Seen || a.Flash != want {
+			t.Errorf("reduced motion %v: flash %d on coming into view, want %d", reduced, a.Flash, want)
+		}
+		for i := 0; i < entryFlashTicks; i++ {
+			a.Update(g)
+		}
+		if a.Flash != 0 {
+			t.Errorf("still flashing %d ticks after the flash ended", a.Flash)
+		}
+	}
+}
+
+func TestStudyMode(t *testing.T) {
+	defer func(l *log.Logger) { studyLog = l }(studyLog)
+
+	a := &Asteroid{
+		Object:    &Object{Center: image.Pt(104, 100), Radius: 10},
+		Explosion: &Explosion{},
+		Alive:     true,
+		ID:        42,
+	}
+	c := &Crosshair{Object: &Object{Center: image.Pt(100, 100), Radius: 10}}
+
+	studyLog = nil
+	c.Targets(Asteroids{a}, true, 7) // nothing to log to, mustn't panic
+
+	var buf bytes.Buffer
+	studyLog = log.New(&buf, "", 0)
+	c.Targets(Asteroids{a}, true, 7)
+	want := "tick=7 check=shot asteroid=42 at=104,100 r=10.0 distance=0.0 other=100,100 r=10.0 hit=true\n"
+	if buf.String() != want {
+		t.Errorf("study log %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	a.Alive = false
+	c.Targets(Asteroids{a}, true, 8)
+	if buf.Len() != 0 {
+		t.Errorf("check on a dead asteroid logged %q", buf.String())
+	}
+
+	as := NewAsteroids(ebiten.NewImage(8, 8), ebiten.NewImage(8, 8), rand.New(rand.NewSource(1)), 10, 3)
+	if as[0].ID == as[1].ID || as[1].ID == as[2].ID || as[0].ID == as[2].ID {
+		t.Errorf("asteroids share IDs %d, %d, %d", as[0].ID, as[1].ID, as[2].ID)
+	}
+}
+
+func TestExplosionLifetime(t *testing.T) {
+	for _, tps := range []int{30, 60, 120} {
+		dt := 1 / float64(tps)
+		e := &Explosion{Frame: 1, Exploding: true}
+		ticks, last := 0, e.Frame
+		for !e.Done {
+			e.advance(dt)
+			ticks++
+			if e.Exploding && e.Frame < last {
+				t.Errorf("%d TPS: animation went back from frame %d to %d", tps, last, e.Frame)
+			}
+			last = e.Frame
+			if ticks > 1000 {
+				t.Fatalf("%d TPS: explosion never finished", tps)
+			}
+		}
+		seconds := float64(ticks) * dt
+		want := explosionFrames * explosionFrameTime
+		if seconds < want-1e-9 || seconds > want+dt {
+			t.Errorf("%d TPS: explosion lasted %vs, want %vs", tps, seconds, want)
+		}
+		if e.Exploding || e.Frame != 1 || e.Elapsed != 0 {
+			t.Errorf("%d TPS: explosion not reset when done", tps)
+		}
+	}
+
+	// At the usual 60 TPS every frame is still shown for exactly one tick
+	e := &Explosion{Frame: 1, Exploding: true}
+	for want := 2; want <= explosionFrames; want++ {
+		e.advance(1.0 / 60)
+		if e.Frame != want {
+			t.Errorf("frame %d at 60 TPS, want %d", e.Frame, want)
+		}
+	}
+	e.advance(1.0 / 60)
+	if !e.Done {
+		t.Errorf("explosion not done after %d ticks at 60 TPS", explosionFrames)
+	}
+}
+
+func TestMoonOrbitRadius(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, angle := range []float64{0, 1, 2.5, -4} {
+		g.Moon.OrbitAngle = angle
+		g.Moon.Update(g)
+		d := g.Moon.Center.Sub(image.Pt(g.Width/2, g.Height/2))
+		if got := math.Hypot(float64(d.X), float64(d.Y)); math.Abs(got-g.Moon.OrbitRadius(g)) > 1.5 {
+			t.Errorf("moon at %v: %v from the Earth, orbit drawn at %v", angle, got, g.Moon.OrbitRadius(g))
+		}
+	}
+}
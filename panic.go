@@ -0,0 +1,58 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// panicFlashTicks is how long the screen flashes for after the panic button
+var panicFlashTicks = ebiten.MaxTPS() / 3
+
+// panicPressed is shorthand for when the panic key, B unless rebound, (or the
+// second gamepad button) has just been pressed
+func panicPressed() bool {
+	for _, id := range ebiten.GamepadIDs() {
+		if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton1) {
+			return true
+		}
+	}
+	return inpututil.IsKeyJustPressed(controls.Keys[ActionPanic])
+}
+
+// Panic uses up the wave's charge to destroy every asteroid within
+// PanicRadius of the Earth, and returns how many it got
+func (g *Game) Panic() int {
+	if !g.PanicReady {
+		return 0
+	}
+	g.PanicReady = false
+	n := 0
+	for _, v := range g.Asteroids {
+		if v.Alive && !v.Explosion.Exploding && v.Distance < PanicRadius {
+			v.Explosion.Exploding = true
+			g.Count--
+			g.chainSpawn()
+			n++
+		}
+	}
+	log.Printf("panic button destroyed %d asteroids\n", n)
+	play(g.Sounds.ExplsnLo)
+	if !ReducedMotion {
+		g.Flash = panicFlashTicks
+	}
+	return n
+}
+
+// drawPanicFlash fades out a white flash over the whole screen
+func drawPanicFlash(screen *ebiten.Image, g *Game) {
+	a := uint8(192 * g.Flash / panicFlashTicks)
+	ebitenutil.DrawRect(screen, 0, 0, float64(g.Width), float64(g.Height), color.RGBA{a, a, a, a})
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+)
+
+// practiceAngle is the fixed path practice targets come in on, from the top
+const practiceAngle = -math.Pi / 2
+
+// PracticeStats keeps track of how quickly the player hits practice targets
+type PracticeStats struct {
+	Hits         int
+	TotalTicks   int
+	LastTicks    int     // from the target coming into view until it was hit
+	LastDistance float64 // how far from the Earth the target was when hit
+}
+
+// Record adds a hit on a target that was in view for ticks, at distance
+func (p *PracticeStats) Record(ticks int, distance float64) {
+	p.Hits++
+	p.TotalTicks += ticks
+	p.LastTicks = ticks
+	p.LastDistance = distance
+}
+
+// AverageTicks is the mean reaction time over all hits so far
+func (p *PracticeStats) AverageTicks() float64 {
+	if p.Hits == 0 {
+		return 0
+	}
+	return float64(p.TotalTicks) / float64(p.Hits)
+}
+
+// Draw shows the reaction times along the bottom of the screen
+func (p *PracticeStats) Draw(screen *ebiten.Image, face font.Face, width, y int) {
+	if p.Hits == 0 {
+		drawTextCentred(screen, "PRACTICE: SHOOT THE TARGET", face, width/2, y)
+		return
+	}
+	ms := func(ticks float64) int { return int(ticks * 1000 / float64(ebiten.MaxTPS())) }
+	drawTextCentred(screen, fmt.Sprintf(
+		"REACTION %dMS AT %.0f AVERAGE %dMS",
+		ms(float64(p.LastTicks)), p.LastDistance, ms(p.AverageTicks()),
+	), face, width/2, y)
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestPracticeStats(t *testing.T) {
+	var p PracticeStats
+	if p.AverageTicks() != 0 {
+		t.Errorf("average with no hits is %v", p.AverageTicks())
+	}
+	p.Record(30, 200)
+	p.Record(10, 100)
+	if p.Hits != 2 || p.LastTicks != 10 || p.LastDistance != 100 {
+		t.Errorf("stats after two hits: %+v", p)
+	}
+	if p.AverageTicks() != 20 {
+		t.Errorf("average is %v, want 20", p.AverageTicks())
+	}
+}
+
+func TestPracticeHit(t *testing.T) {
+	Practice = true
+	defer func() { Practice = false }()
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 1
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.Restart()
+	target := g.Asteroids[0]
+	if target.Angle != practiceAngle {
+		t.Errorf("practice target at angle %v, want the fixed %v", target.Angle, practiceAngle)
+	}
+
+	for !target.Seen {
+		g.Tick++
+		g.Asteroids.Update(g)
+	}
+	for i := 0; i < 15; i++ {
+		g.Tick++
+		g.Asteroids.Update(g)
+	}
+	g.Crosshair.Center = target.Center
+	g.Crosshair.Shoot(g)
+	if g.Practice.Hits != 1 || g.Practice.LastTicks != 15 {
+		t.Errorf("practice stats after a hit: %+v", g.Practice)
+	}
+	if g.Practice.LastDistance != target.Distance {
+		t.Errorf("hit recorded at %v, target was at %v", g.Practice.LastDistance, target.Distance)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/font"
+)
+
+// A Profile is a set of asteroid settings the player can pick between on the
+// title screen
+type Profile struct {
+	ID               string
+	Name             string
+	HowManyStart     int     // asteroids in the first wave
+	WaveMultiplier   int     // how many times more in each wave after
+	AsteroidSpeed    float64 // how fast they fall
+	DistanceVariance float64 // how spread out they come in
+	SpawnGap         int     // ticks between new asteroids in time attack
+	FuseChance       float64 // share of asteroids that come in fused
+}
+
+// profiles are all the difficulty profiles, from easiest to hardest
+var profiles = []Profile{
+	{"gentle", "GENTLE", 3, 2, 0.6, 9, ebiten.MaxTPS(), 0},
+	{"normal", "NORMAL", 5, 2, 1, 7, ebiten.MaxTPS() / 2, 0},
+	{"fierce", "FIERCE", 6, 2, 1.5, 5, ebiten.MaxTPS() * 2 / 5, 0.2},
+	{"swarm", "SWARM", 10, 3, 0.8, 4, ebiten.MaxTPS() / 4, 0.1},
+}
+
+// profileNamed is the profile with ID id, if there is one
+func profileNamed(id string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// currentProfile is the profile the tunables are set to right now
+func currentProfile() Profile {
+	return Profile{
+		HowManyStart:     HowManyStart,
+		WaveMultiplier:   WaveMultiplier,
+		AsteroidSpeed:    AsteroidSpeed,
+		DistanceVariance: DistanceVariance,
+		SpawnGap:         timeAttackSpawnGap,
+		FuseChance:       FuseChance,
+	}
+}
+
+// apply sets the tunables from the profile
+func (p Profile) apply() {
+	HowManyStart = p.HowManyStart
+	WaveMultiplier = p.WaveMultiplier
+	AsteroidSpeed = p.AsteroidSpeed
+	DistanceVariance = p.DistanceVariance
+	timeAttackSpawnGap = p.SpawnGap
+	FuseChance = p.FuseChance
+}
+
+// applyProfile sets the tunables from a profile, ready for the next run
+func (g *Game) applyProfile(p Profile) {
+	p.apply()
+	if Practice {
+		HowManyStart = 1
+	}
+	g.HowMany = HowManyStart
+	g.Base = currentTunables() // so starting a run doesn't put them back
+}
+
+// ChosenProfile is the difficulty profile chosen on the title screen if there
+// is one, or empty
+func (s *SaveFile) ChosenProfile() string {
+	if _, ok := profileNamed(s.Difficulty); ok {
+		return s.Difficulty
+	}
+	return ""
+}
+
+// previewSize is how big the box the profile preview plays in is, in pixels,
+// and previewScale how much smaller everything is drawn in it
+const previewSize, previewScale = 160, 0.15
+
+// A ProfilePreview is a tiny game of its own, its asteroids spawned and
+// falling on a copy of the Earth the way a profile has them, without touching
+// the real one
+type ProfilePreview struct {
+	Showing int   // which profile is being previewed
+	game    *Game // the preview's own game, only ever holding asteroids
+	rand    *rand.Rand
+}
+
+// previewRocks is how many asteroids the preview shows for a profile at once,
+// up to a dozen so it stays readable
+func previewRocks(p Profile) int {
+	if p.HowManyStart > 12 {
+		return 12
+	}
+	return p.HowManyStart
+}
+
+// Reset starts the preview of the profile at index i again, on a game of its
+// own the same size as g
+func (v *ProfilePreview) Reset(g *Game, i int) {
+	v.Showing = i
+	if v.rand == nil {
+		v.rand = rand.New(rand.NewSource(1))
+	}
+	earth := *g.Earth
+	v.game = &Game{
+		Width:    g.Width,
+		Height:   g.Height,
+		Wave:     1,
+		Earth:    &earth,
+		Images:   g.Images,
+		Rand:     v.rand,
+		Sounds:   &Sounds{}, // silent
+		Entities: []Entity{Asteroids{}},
+	}
+	v.spawn(previewRocks(profiles[i]))
+}
+
+// spawn sends howMany asteroids into the preview with the profile's tunables,
+// putting the real ones back after
+func (v *ProfilePreview) spawn(howMany int) {
+	defer currentProfile().apply()
+	profiles[v.Showing].apply()
+	v.game.Spawn(howMany)
+}
+
+// Update plays the preview on a tick, sending in another asteroid as often as
+// the profile does in time attack while there's room for it
+func (v *ProfilePreview) Update() {
+	p := profiles[v.Showing]
+	g := v.game
+	g.Tick++
+	g.Asteroids.Update(g)
+
+	alive := g.Asteroids[:0]
+	for _, a := range g.Asteroids {
+		if a.Alive {
+			alive = append(alive, a)
+		}
+	}
+	g.Asteroids = alive
+	if len(g.Asteroids) < previewRocks(p) && (p.SpawnGap <= 0 || g.Tick%p.SpawnGap == 0) {
+		v.spawn(1)
+	}
+}
+
+// updateProfiles flicks through the profiles with the arrow keys, starting
+// each one's preview again, and picks the one showing with Enter or a click
+func (g *Game) updateProfiles() {
+	n := len(profiles)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		g.Preview.Reset(g, (g.Preview.Showing+n-1)%n)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		g.Preview.Reset(g, (g.Preview.Showing+1)%n)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter), clicked():
+		p := profiles[g.Preview.Showing]
+		g.applyProfile(p)
+		g.Save.Difficulty = p.ID
+		if err := g.Save.Write(SaveFileName); err != nil {
+			log.Printf("error writing save file: %v\n", err)
+		}
+		log.Printf("difficulty profile chosen: %s\n", p.Name)
+		g.ShowPicker = false
+		return
+	}
+	g.Preview.Update()
+}
+
+// drawProfiles shows the previewed profile's settings on a panel, with its
+// preview playing in a box left empty at the bottom of it
+func drawProfiles(screen *ebiten.Image, g *Game) {
+	p := profiles[g.Preview.Showing]
+	chosen := ""
+	if g.Save.ChosenProfile() == p.ID {
+		chosen = " (CHOSEN)"
+	}
+	lines := []string{
+		fmt.Sprintf("< %s >%s", p.Name, chosen),
+		fmt.Sprintf("%d ASTEROIDS, %d TIMES MORE EACH WAVE", p.HowManyStart, p.WaveMultiplier),
+		fmt.Sprintf("FALLING AT %.0f%% SPEED", p.AsteroidSpeed*100),
+		fmt.Sprintf("A NEW ONE EVERY %.2gS IN TIME ATTACK", float64(p.SpawnGap)/float64(ebiten.MaxTPS())),
+		fmt.Sprintf("%.0f%% OF THEM FUSED", p.FuseChance*100),
+		"ENTER OR CLICK TO CHOOSE",
+	}
+	f, _ := font.BoundString(g.FontFace, "0")
+	lineH := (f.Max.Y - f.Min.Y).Ceil() * 2
+	room := (previewSize + lineH - 1) / lineH
+	for i := 0; i < room; i++ {
+		lines = append(lines, "")
+	}
+	drawPanel(screen, g.FontFace, g.Width, g.Height, "DIFFICULTY", lines)
+
+	panelH := lineH * (len(lines) + 2)
+	x := float64(g.Width/2 - previewSize/2)
+	y := float64(g.Height/2 + panelH/2 - lineH/4 - room*lineH)
+	ebitenutil.DrawRect(screen, x, y, previewSize, previewSize, hud.Panel)
+	cx, cy := x+previewSize/2, y+previewSize/2
+
+	earth := float64(g.Earth.Image.Bounds().Dx())
+	op := &ebiten.DrawImageOptions{Filter: spriteFilter()}
+	op.GeoM.Translate(-earth/2, -earth/2)
+	op.GeoM.Scale(previewScale, previewScale)
+	op.GeoM.Translate(cx, cy)
+	screen.DrawImage(g.Earth.Image, op)
+
+	// The preview's game is the same size as the real one, shrunk into the box
+	for _, a := range g.Preview.game.Asteroids {
+		if a.Explosion.Exploding {
+			continue
+		}
+		dx := float64(a.Center.X-g.Width/2) * previewScale
+		dy := float64(a.Center.Y-g.Height/2) * previewScale
+		if math.Abs(dx) > previewSize/2 || math.Abs(dy) > previewSize/2 {
+			continue // still coming in from outside the box
+		}
+		op := &ebiten.DrawImageOptions{Filter: spriteFilter(), GeoM: a.Op.GeoM, ColorM: a.Op.ColorM}
+		op.GeoM.Translate(-float64(g.Width/2), -float64(g.Height/2))
+		op.GeoM.Scale(previewScale, previewScale)
+		op.GeoM.Translate(cx, cy)
+		screen.DrawImage(a.Image, op)
+		if a.Fuse > 0 {
+			ebitenutil.DrawRect(screen, cx+dx-1, cy+dy-1, 3, 3, hud.Warning)
+		}
+	}
+}
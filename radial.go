@@ -0,0 +1,108 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// radialSize is how far from where the radial menu opened its abilities are
+// drawn, and radialDeadZone how far the crosshair has to move to pick one
+const radialSize, radialDeadZone = 90, 20
+
+// An Ability is a special power the player can pick from the radial menu
+type Ability struct {
+	Name  string
+	Ready func(g *Game) bool // whether it can be used right now
+	Use   func(g *Game)
+}
+
+// abilities are the special powers that are turned on, in the order they go
+// round the radial menu clockwise from the top
+func (g *Game) abilities() []Ability {
+	var as []Ability
+	if PanicButton {
+		as = append(as, Ability{
+			Name:  "PANIC",
+			Ready: func(g *Game) bool { return g.PanicReady },
+			Use:   func(g *Game) { g.Panic() },
+		})
+	}
+	if TractorBeam {
+		as = append(as, Ability{
+			Name:  "TRACTOR",
+			Ready: func(g *Game) bool { return g.TractorOK },
+			Use:   func(g *Game) { g.Tractor() },
+		})
+	}
+	return as
+}
+
+// AbilityMenu is the radial menu of abilities shown around the crosshair while
+// its key is held, picking whichever way the crosshair is moved when it's let go
+type AbilityMenu struct {
+	Open   bool
+	Origin image.Point // where the crosshair was when the menu opened
+	Choice int         // index of the picked ability, -1 for none
+}
+
+// updateRadial opens the radial menu while the abilities key is held, follows
+// which ability the crosshair points at and uses it when the key is let go
+func (g *Game) updateRadial() {
+	abilities := g.abilities()
+	m := &g.Radial
+	if ebiten.IsKeyPressed(controls.Keys[ActionAbilities]) && len(abilities) > 0 {
+		if !m.Open {
+			m.Open = true
+			m.Origin = g.Crosshair.Center
+		}
+		d := g.Crosshair.Center.Sub(m.Origin)
+		m.Choice = radialChoice(float64(d.X), float64(d.Y), len(abilities))
+		return
+	}
+	if m.Open {
+		m.Open = false
+		if m.Choice >= 0 && m.Choice < len(abilities) && abilities[m.Choice].Ready(g) {
+			abilities[m.Choice].Use(g)
+		}
+	}
+}
+
+// radialChoice is which of n slices of the radial menu the direction dx, dy
+// points into, numbered clockwise from straight up, or -1 if it's too short a
+// move to count
+func radialChoice(dx, dy float64, n int) int {
+	if n <= 0 || math.Hypot(dx, dy) < radialDeadZone {
+		return -1
+	}
+	slice := 2 * math.Pi / float64(n)
+	angle := math.Atan2(dx, -dy) // clockwise from up
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return int((angle+slice/2)/slice) % n
+}
+
+// drawRadial draws the abilities round where the radial menu opened, with the
+// picked one in brackets
+func drawRadial(screen *ebiten.Image, g *Game) {
+	abilities := g.abilities()
+	for i, a := range abilities {
+		angle := 2 * math.Pi * float64(i) / float64(len(abilities))
+		x := g.Radial.Origin.X + int(radialSize*math.Sin(angle))
+		y := g.Radial.Origin.Y - int(radialSize*math.Cos(angle))
+		name := a.Name
+		if !a.Ready(g) {
+			name += " (USED)"
+		}
+		if i == g.Radial.Choice {
+			name = "[" + name + "]"
+		}
+		drawTextCentred(screen, name, g.FontFace, x, y)
+	}
+}
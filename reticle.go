@@ -0,0 +1,70 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// A Reticle is the shape the crosshair takes to show what clicking would do
+type Reticle int
+
+const (
+	// ReticleNeutral is the normal crosshair
+	ReticleNeutral Reticle = iota
+	// ReticleReady is over an asteroid that a shot would hit
+	ReticleReady
+	// ReticleBlocked is cooling down after a miss and can't shoot
+	ReticleBlocked
+)
+
+// reticleFor picks the crosshair's shape for the current situation
+func reticleFor(coolingDown, onTarget bool) Reticle {
+	switch {
+	case coolingDown:
+		return ReticleBlocked
+	case onTarget:
+		return ReticleReady
+	}
+	return ReticleNeutral
+}
+
+// newReticles draws the shapes of the crosshair from its normal image, all
+// the same size so its Radius stays the same whichever is showing
+func newReticles(base *ebiten.Image) map[Reticle]*ebiten.Image {
+	w, h := base.Size()
+
+	ready := ebiten.NewImage(w, h)
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(0.4, 1, 0.4, 1)
+	ready.DrawImage(base, op)
+	ebitenutil.DrawRect(ready, float64(w)/2-2, float64(h)/2-2, 4, 4, color.RGBA{100, 255, 100, 255})
+
+	blocked := ebiten.NewImage(w, h)
+	op = &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(1, 0.3, 0.3, 0.6)
+	blocked.DrawImage(base, op)
+	ebitenutil.DrawLine(blocked, 0, 0, float64(w), float64(h), color.RGBA{255, 60, 60, 255})
+	ebitenutil.DrawLine(blocked, float64(w), 0, 0, float64(h), color.RGBA{255, 60, 60, 255})
+
+	return map[Reticle]*ebiten.Image{
+		ReticleNeutral: base,
+		ReticleReady:   ready,
+		ReticleBlocked: blocked,
+	}
+}
+
+// onTarget reports whether the crosshair is over an asteroid it could hit
+func (o *Crosshair) onTarget(as Asteroids) bool {
+	for _, v := range as {
+		if v.Alive && !v.Explosion.Exploding && o.Overlaps(v.Object) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// RewindSeconds is how far back in time a rewind goes
+const RewindSeconds = 2
+
+// AsteroidState is the part of an Asteroid that changes as the game plays
+type AsteroidState struct {
+	Angle     float64
+	Distance  float64
+	Spin      float64
+	Alive     bool
+	Impacting bool
+	Seen      bool
+	Health    int
+	VX, VY    float64
+	Burn      float64
+	Frame     int
+	Elapsed   float64
+	Exploding bool
+	Done      bool
+}
+
+// A Snapshot is the state of a wave at one moment, enough to go back to it
+type Snapshot struct {
+	Rotation  float64
+	MoonAngle float64
+	Count     int
+	Score     int
+	Asteroids []AsteroidState
+}
+
+// TakeSnapshot records the current state of the wave
+func (g *Game) TakeSnapshot() Snapshot {
+	s := Snapshot{
+		Rotation:  g.Rotation,
+		MoonAngle: g.moonAngle(),
+		Count:     g.Count,
+		Score:     g.Score,
+		Asteroids: make([]AsteroidState, len(g.Asteroids)),
+	}
+	for i, v := range g.Asteroids {
+		s.Asteroids[i] = AsteroidState{
+			Angle:     v.Angle,
+			Distance:  v.Distance,
+			Spin:      v.Spin,
+			Alive:     v.Alive,
+			Impacting: v.Impacting,
+			Seen:      v.Seen,
+			Health:    v.Health,
+			VX:        v.VX,
+			VY:        v.VY,
+			Burn:      v.Burn,
+			Frame:     v.Explosion.Frame,
+			Elapsed:   v.Explosion.Elapsed,
+			Exploding: v.Explosion.Exploding,
+			Done:      v.Explosion.Done,
+		}
+	}
+	return s
+}
+
+// RestoreSnapshot puts the wave back how it was in s, which must have been
+// taken during the same wave
+func (g *Game) RestoreSnapshot(s Snapshot) {
+	g.Rotation = s.Rotation
+	if g.Moon != nil {
+		g.Moon.OrbitAngle = s.MoonAngle
+	}
+	g.Count = s.Count
+	g.Score = s.Score
+	for i, v := range s.Asteroids {
+		if i >= len(g.Asteroids) {
+			break
+		}
+		a := g.Asteroids[i]
+		a.Angle, a.Distance, a.Spin = v.Angle, v.Distance, v.Spin
+		a.Alive, a.Impacting, a.Seen = v.Alive, v.Impacting, v.Seen
+		a.Health = v.Health
+		a.VX, a.VY = v.VX, v.VY
+		a.Burn = v.Burn
+		a.Explosion.Frame, a.Explosion.Elapsed = v.Frame, v.Elapsed
+		a.Explosion.Exploding = v.Exploding
+		a.Explosion.Done = v.Done
+	}
+}
+
+// recordHistory keeps a snapshot of each tick, as far back as a rewind goes
+func (g *Game) recordHistory() {
+	if Rewinds == 0 {
+		return
+	}
+	g.History = append(g.History, g.TakeSnapshot())
+	if max := RewindSeconds * ebiten.MaxTPS(); len(g.History) > max {
+		g.History = g.History[len(g.History)-max:]
+	}
+}
+
+// Rewind goes back to the oldest snapshot, using up one of the run's rewinds,
+// and slows time down briefly. It reports false if there's nothing to rewind.
+func (g *Game) Rewind() bool {
+	if g.Rewinds <= 0 || len(g.History) == 0 {
+		return false
+	}
+	g.Rewinds--
+	g.RestoreSnapshot(g.History[0])
+	g.History = g.History[:0]
+	g.SlowMo = ebiten.MaxTPS()
+	return true
+}
+
+// Heal gives back one of the run's used rewinds, up to the number it started
+// with, and makes the Earth glow. It reports false if none were used.
+func (g *Game) Heal() bool {
+	if g.Rewinds >= Rewinds {
+		return false
+	}
+	g.Rewinds++
+	g.Earth.Healing = healGlowTicks
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestRewind(t *testing.T) {
+	defer func(r int) { Rewinds = r }(Rewinds)
+	Rewinds = 2
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.HowMany = 3
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.Rewinds = Rewinds
+	g.Restart()
+
+	g.recordHistory()
+	before := g.TakeSnapshot()
+	for i := 0; i < 30; i++ {
+		g.Rotation -= RotationSpeed
+		g.Asteroids.Update(g)
+		g.recordHistory()
+	}
+	g.Asteroids[0].Explosion.Exploding = true
+	g.Count--
+	if g.Asteroids[0].Distance == before.Asteroids[0].Distance {
+		t.Fatalf("asteroids didn't move")
+	}
+
+	if !g.Rewind() {
+		t.Fatalf("couldn't rewind")
+	}
+	if g.Rewinds != 1 {
+		t.Errorf("%d rewinds left, want 1", g.Rewinds)
+	}
+	after := g.TakeSnapshot()
+	if after.Rotation != before.Rotation || after.Count != before.Count {
+		t.Errorf("rewound to rotation %v count %d, want %v and %d",
+			after.Rotation, after.Count, before.Rotation, before.Count)
+	}
+	for i := range before.Asteroids {
+		if after.Asteroids[i] != before.Asteroids[i] {
+			t.Errorf("asteroid %d rewound to %+v, want %+v", i, after.Asteroids[i], before.Asteroids[i])
+		}
+	}
+	if g.SlowMo == 0 {
+		t.Errorf("no slow motion after rewinding")
+	}
+
+	if g.Rewind() {
+		t.Errorf("rewound again with no history")
+	}
+	g.Rewinds = 0
+	g.recordHistory()
+	if g.Rewind() {
+		t.Errorf("rewound with no rewinds left")
+	}
+}
+
+func TestHeal(t *testing.T) {
+	defer func(r int) { Rewinds = r }(Rewinds)
+	Rewinds = 2
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Rewinds = 0
+
+	if !g.Heal() || g.Rewinds != 1 {
+		t.Errorf("heal left %d rewinds, want 1", g.Rewinds)
+	}
+	if g.Earth.Healing == 0 {
+		t.Errorf("Earth didn't glow when healed")
+	}
+	if !g.Heal() || g.Rewinds != 2 {
+		t.Errorf("heal left %d rewinds, want 2", g.Rewinds)
+	}
+	if g.Heal() || g.Rewinds != 2 {
+		t.Errorf("heal went past the starting rewinds to %d", g.Rewinds)
+	}
+
+	for i := 0; i < healGlowTicks; i++ {
+		g.Earth.Update(g)
+	}
+	if g.Earth.Healing != 0 {
+		t.Errorf("Earth still glowing %d ticks after healing", g.Earth.Healing)
+	}
+}
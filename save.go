@@ -0,0 +1,145 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// SaveFileName is where the save file is kept, next to the config file
+var SaveFileName = "lunar-defence.save"
+
+// SaveVersion is the version of the save file's schema, bump it and add a
+// migration whenever a change to SaveFile would break older saves
+const SaveVersion = 2
+
+// A SaveFile is everything that is kept between runs of the game
+type SaveFile struct {
+	Version        int
+	Leaderboard    Leaderboard
+	Achievements   []string // IDs of unlocked achievements
+	TimeAttackBest int      // best score in time attack mode
+	BestStreak     int      // most waves survived in a single run
+	Cosmetics      []string // IDs of unlocked cosmetics
+	Theme          string   // HUD theme chosen on the title screen
+	Controls       string   // controls preset chosen on the title screen
+	Difficulty     string   // difficulty profile chosen on the title screen
+
+	unwritable bool // the save file couldn't be read or moved aside, so leave it be
+}
+
+// migrations upgrade the raw data of a save file from the version they're
+// keyed by to the next one
+var migrations = map[int]func(data map[string]interface{}){
+	// Version 1 had no version number and wrote empty lists as null
+	1: func(data map[string]interface{}) {
+		for _, k := range []string{"Leaderboard", "Achievements"} {
+			if data[k] == nil {
+				data[k] = []interface{}{}
+			}
+		}
+	},
+}
+
+// errSaveTooNew is the error for a save file written by a newer version of
+// the game than this one
+var errSaveTooNew = errors.New("save file is newer than this game")
+
+// migrate upgrades the raw data of a save file from version to SaveVersion
+func migrate(version int, data map[string]interface{}) error {
+	if version > SaveVersion {
+		return fmt.Errorf("%w: version %d, this game's is %d", errSaveTooNew, version, SaveVersion)
+	}
+	for v := version; v < SaveVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return fmt.Errorf("no migration from save file version %d", v)
+		}
+		m(data)
+	}
+	data["Version"] = SaveVersion
+	return nil
+}
+
+// LoadSave reads the save file at path, a missing file or empty path is an
+// empty save. A save file that's there but can't be read is moved aside to a
+// backup and an empty save used instead, so saving doesn't overwrite it. One
+// from a newer version of the game is left where it is and never saved over,
+// for when that version is played again.
+func LoadSave(path string) (*SaveFile, error) {
+	if path == "" {
+		return &SaveFile{Version: SaveVersion}, nil
+	}
+	save, err := readSave(path)
+	if err == nil {
+		return save, nil
+	}
+
+	save = &SaveFile{Version: SaveVersion}
+	if errors.Is(err, errSaveTooNew) {
+		save.unwritable = true
+		return save, fmt.Errorf("%v, so it won't be saved over", err)
+	}
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if rerr := os.Rename(path, backup); rerr != nil {
+		save.unwritable = true
+		return save, fmt.Errorf("%v, and couldn't back it up, so it won't be saved over: %v", err, rerr)
+	}
+	return save, fmt.Errorf("%v, moved it to %s and started a new save", err, backup)
+}
+
+// readSave reads the save file at path, a missing file is an empty save
+func readSave(path string) (*SaveFile, error) {
+	save := &SaveFile{Version: SaveVersion}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return save, nil
+	}
+	if err != nil {
+		return save, err
+	}
+
+	// Upgrade older saves before reading them
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return save, err
+	}
+	version := 1
+	if v, ok := raw["Version"].(float64); ok {
+		version = int(v)
+	}
+	if err := migrate(version, raw); err != nil {
+		return save, err
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		return save, err
+	}
+
+	if err := json.Unmarshal(data, save); err != nil {
+		return &SaveFile{Version: SaveVersion}, err
+	}
+	return save, nil
+}
+
+// Write writes the save file to path, an empty path means don't save
+func (s *SaveFile) Write(path string) error {
+	if path == "" {
+		return nil
+	}
+	if s.unwritable {
+		return errors.New("not saving over a save file that couldn't be read")
+	}
+	s.Version = SaveVersion
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
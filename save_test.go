@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSaveMigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1.save")
+	v1 := `{
+	"Leaderboard": [
+		{"Initials": "ABC", "Score": 12, "Wave": 3, "Time": "2020-11-01T12:00:00Z"}
+	],
+	"Achievements": null
+}`
+	if err := os.WriteFile(path, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	save, err := LoadSave(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if save.Version != SaveVersion {
+		t.Errorf("version %d, want %d", save.Version, SaveVersion)
+	}
+	if len(save.Leaderboard) != 1 || save.Leaderboard[0].Initials != "ABC" || save.Leaderboard[0].Score != 12 {
+		t.Errorf("leaderboard %v didn't survive migrating", save.Leaderboard)
+	}
+	if save.Achievements == nil || len(save.Achievements) != 0 {
+		t.Errorf("achievements %#v, want an empty list", save.Achievements)
+	}
+}
+
+func TestLoadSaveTooNew(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.save")
+	newer := []byte(`{"Version": 999}`)
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+	save, err := LoadSave(path)
+	if err == nil {
+		t.Errorf("loaded a save from a newer version of the game")
+	}
+
+	// The newer save stays where it is, without a backup, and isn't saved over
+	if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+		t.Errorf("newer save file moved aside to %v", backups)
+	}
+	if err := save.Write(path); err == nil {
+		t.Errorf("saved over a save from a newer version of the game")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(newer) {
+		t.Errorf("newer save file is now %q, want it left alone", data)
+	}
+}
+
+func TestLoadSaveMissing(t *testing.T) {
+	dir := t.TempDir()
+	save, err := LoadSave(filepath.Join(dir, "missing.save"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if save.Version != SaveVersion || len(save.Leaderboard) != 0 {
+		t.Errorf("missing save file loaded as %+v, want an empty save", save)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("loading a missing save file left %d files behind", len(files))
+	}
+}
+
+func TestLoadSaveUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.save")
+	broken := []byte(`{"Version": 2, "Leaderboard": [{"Initials": "AB`)
+	if err := os.WriteFile(path, broken, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	save, err := LoadSave(path)
+	if err == nil {
+		t.Errorf("no error loading a broken save file")
+	}
+	if save == nil || save.Version != SaveVersion || len(save.Leaderboard) != 0 {
+		t.Fatalf("broken save file loaded as %+v, want an empty save", save)
+	}
+
+	// The broken file is kept as a backup that saving doesn't touch
+	backups, _ := filepath.Glob(path + ".*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("%d backups of the broken save file, want 1", len(backups))
+	}
+	save.Leaderboard.Add(ScoreEntry{Initials: "NEW", Score: 3})
+	if err := save.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != string(broken) {
+		t.Errorf("backup is %q, want the broken save file", data)
+	}
+	if again, err := LoadSave(path); err != nil || len(again.Leaderboard) != 1 {
+		t.Errorf("new save didn't load back: %v", err)
+	}
+
+	// If the broken file can't be moved aside it's never saved over
+	stuck := &SaveFile{unwritable: true}
+	if err := stuck.Write(path); err == nil {
+		t.Errorf("saved over a save file that couldn't be backed up")
+	}
+}
+
+func TestHudTheme(t *testing.T) {
+	s := &SaveFile{}
+	if got := s.HudTheme("amber"); got != "amber" {
+		t.Errorf("theme with none chosen %q, want the config's amber", got)
+	}
+	s.Theme = "nope"
+	if got := s.HudTheme("amber"); got != "amber" {
+		t.Errorf("theme with an unknown one chosen %q, want amber", got)
+	}
+
+	// Cycling goes through every theme and back round to the start
+	name := "white"
+	seen := map[string]bool{}
+	for range hudThemeNames {
+		seen[name] = true
+		name = nextHudTheme(name)
+	}
+	if name != "white" || len(seen) != len(hudThemes) {
+		t.Errorf("cycling themes saw %v and ended on %q", seen, name)
+	}
+
+	s.Theme = "blue"
+	setHudTheme(s.HudTheme("white"))
+	defer setHudTheme("white")
+	if hud != hudThemes["blue"] {
+		t.Errorf("HUD not drawn in the chosen theme")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A ScheduledSpawn is one asteroid in a hand-made level, coming in exactly when
+// and where it says
+type ScheduledSpawn struct {
+	Tick     int     // ticks after the wave starts
+	Angle    float64 // radians round the Earth
+	Distance float64 // from the Earth's surface
+	Speed    float64 // how far it falls each tick, 0 for normal speed
+	Health   int     // shots to destroy it, 0 for the wave's usual health
+}
+
+// A Schedule is a hand-made level, the asteroids of each of its waves in the
+// order they come in, going back round to the first wave after the last
+type Schedule struct {
+	Name  string
+	Waves [][]ScheduledSpawn
+}
+
+// LoadSchedule reads and checks the level called name from the embedded assets
+func LoadSchedule(name string) (*Schedule, error) {
+	data, err := assets.ReadFile("assets/levels/" + name + ".json")
+	if err != nil {
+		return nil, err
+	}
+	return ParseSchedule(data)
+}
+
+// ParseSchedule decodes a level from JSON and checks it makes sense
+func ParseSchedule(data []byte) (*Schedule, error) {
+	s := &Schedule{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate reports the first problem with a level, like an asteroid coming
+// in before the one listed ahead of it
+func (s *Schedule) Validate() error {
+	if len(s.Waves) == 0 {
+		return errors.New("level has no waves")
+	}
+	for w, wave := range s.Waves {
+		if len(wave) == 0 {
+			return fmt.Errorf("wave %d has no asteroids", w+1)
+		}
+		for i, v := range wave {
+			switch {
+			case v.Tick < 0:
+				return fmt.Errorf("wave %d asteroid %d comes in before the wave starts", w+1, i+1)
+			case i > 0 && v.Tick < wave[i-1].Tick:
+				return fmt.Errorf("wave %d asteroid %d comes in before the one ahead of it", w+1, i+1)
+			case v.Distance <= 0 || math.IsNaN(v.Angle) || math.IsInf(v.Angle, 0):
+				return fmt.Errorf("wave %d asteroid %d has nowhere to come in from", w+1, i+1)
+			case v.Speed < 0 || v.Health < 0:
+				return fmt.Errorf("wave %d asteroid %d has a negative speed or health", w+1, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Wave is the asteroids of the level's wave, counting from 1
+func (s *Schedule) Wave(wave int) []ScheduledSpawn {
+	if wave < 1 {
+		wave = 1
+	}
+	return s.Waves[(wave-1)%len(s.Waves)]
+}
+
+// Pending reports whether any asteroids of the current wave are still to come
+func (g *Game) Pending() bool {
+	if g.Schedule == nil {
+		return false
+	}
+	wave := g.Schedule.Wave(g.Wave)
+	return wave[len(wave)-1].Tick >= g.LevelTick
+}
+
+// updateSchedule sends in the level's asteroids that are due this tick
+func (g *Game) updateSchedule() {
+	if g.Wave == 0 || g.GameOver || g.Breathless {
+		return // nothing comes in while waiting for the next wave
+	}
+	var due []ScheduledSpawn
+	for _, v := range g.Schedule.Wave(g.Wave) {
+		if v.Tick == g.LevelTick {
+			due = append(due, v)
+		}
+	}
+	g.LevelTick++
+	if len(due) == 0 {
+		return
+	}
+
+	more := NewAsteroids(g.Images["asteroid"], g.Images["explosion"], g.Rand, g.Earth.Radius, len(due))
+	more.SetHealth(asteroidHealth(g.Wave))
+	for i, v := range due {
+		a := more[i]
+		a.Angle, a.Distance = v.Angle, v.Distance
+		if v.Speed > 0 {
+			a.Speed = v.Speed
+		}
+		if v.Health > 0 {
+			a.Health = v.Health
+		}
+	}
+	g.sendIn(more)
+}
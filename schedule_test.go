@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestScheduleSpawns(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Schedule, err = ParseSchedule([]byte(`{"Waves": [[
+		{"Tick": 0, "Angle": 1, "Distance": 500},
+		{"Tick": 5, "Angle": 2, "Distance": 600, "Speed": 2},
+		{"Tick": 5, "Angle": 3, "Distance": 700},
+		{"Tick": 12, "Angle": 4, "Distance": 800, "Health": 2}
+	]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 1
+	g.Restart()
+	if len(g.Asteroids) != 0 || g.Count != 4 {
+		t.Fatalf("level started with %d asteroids out and %d to come, want 0 and 4", len(g.Asteroids), g.Count)
+	}
+
+	spawned := map[int]int{}
+	for tick := 0; tick < 20; tick++ {
+		before := len(g.Asteroids)
+		if !g.Pending() && tick <= 12 {
+			t.Errorf("nothing pending at tick %d", tick)
+		}
+		g.updateSchedule()
+		if n := len(g.Asteroids) - before; n > 0 {
+			spawned[tick] = n
+		}
+	}
+	if len(spawned) != 3 || spawned[0] != 1 || spawned[5] != 2 || spawned[12] != 1 {
+		t.Errorf("spawned %v, want 1 at tick 0, 2 at 5 and 1 at 12", spawned)
+	}
+	if g.Pending() {
+		t.Errorf("still pending after the last spawn")
+	}
+
+	a := g.Asteroids[3]
+	if a.Angle != 4 || a.Distance != 800 || a.Health != 2 || g.Asteroids[1].Speed != 2 || g.Asteroids[2].Speed != 1 {
+		t.Errorf("spawn parameters not followed")
+	}
+
+	// The next wave waits for the breath between waves to be over
+	g.Wave = 2
+	g.Breathless = true
+	g.LevelTick = 0
+	before := len(g.Asteroids)
+	g.updateSchedule()
+	if len(g.Asteroids) != before || g.LevelTick != 0 {
+		t.Errorf("next wave's asteroids came in during the breath")
+	}
+}
+
+func TestScheduleFuses(t *testing.T) {
+	defer func(chance float64) { FuseChance = chance }(FuseChance)
+	FuseChance = 1
+
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Schedule, err = ParseSchedule([]byte(`{"Waves": [[{"Tick": 0, "Angle": 1, "Distance": 500}]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 1
+	g.Restart()
+	g.updateSchedule()
+	if len(g.Asteroids) != 1 || g.Asteroids[0].Fuse == 0 {
+		t.Errorf("level's asteroid came in without a fuse")
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	for name, data := range map[string]string{
+		"no waves":      `{"Waves": []}`,
+		"empty wave":    `{"Waves": [[]]}`,
+		"out of order":  `{"Waves": [[{"Tick": 5, "Distance": 1}, {"Tick": 2, "Distance": 1}]]}`,
+		"before start":  `{"Waves": [[{"Tick": -1, "Distance": 1}]]}`,
+		"no distance":   `{"Waves": [[{"Tick": 0}]]}`,
+		"negative":      `{"Waves": [[{"Tick": 0, "Distance": 1, "Speed": -1}]]}`,
+		"not even JSON": `{"Waves": [`,
+	} {
+		if _, err := ParseSchedule([]byte(data)); err == nil {
+			t.Errorf("%s: level accepted", name)
+		}
+	}
+
+	s, err := LoadSchedule("gauntlet")
+	if err != nil {
+		t.Fatalf("bundled level: %v", err)
+	}
+	if len(s.Wave(len(s.Waves)+1)) != len(s.Waves[0]) {
+		t.Errorf("waves don't go back round to the first after the last")
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import "math"
+
+// scoreFor is how many points destroying an asteroid is worth: ScoreBase,
+// plus ScorePerWave for every wave after the first, ScoreDistance for every
+// 100 pixels it was still out from the Earth and ScoreFused if it was going to
+// self-destruct, rounded and never less than one point
+func scoreFor(a *Asteroid, g *Game) int {
+	score := ScoreBase
+	if g.Wave > 1 {
+		score += ScorePerWave * float64(g.Wave-1)
+	}
+	if a.Distance > 0 {
+		score += ScoreDistance * a.Distance / 100
+	}
+	if a.Fuse > 0 {
+		score += ScoreFused
+	}
+	if points := int(math.Round(score)); points > 1 {
+		return points
+	}
+	return 1
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// bestScore is the score to beat for a new record, in time attack or on the
+// leaderboard
+func (g *Game) bestScore() int {
+	if TimeAttack {
+		return g.Save.TimeAttackBest
+	}
+	return g.Save.Leaderboard.Best()
+}
+
+// screenshotPath is where a screenshot of a score taken at a time is saved,
+// next to the save file, or empty if there's no save file
+func screenshotPath(score int, at time.Time) string {
+	if SaveFileName == "" {
+		return ""
+	}
+	name := fmt.Sprintf("lunar-defence-%d-%s.png", score, at.Format("20060102-150405"))
+	return filepath.Join(filepath.Dir(SaveFileName), name)
+}
+
+// recordShowing reports whether the game over screen is showing off a new
+// record, once the initials are in and the leaderboard with it is up
+func (g *Game) recordShowing() bool {
+	return g.GameOver && !g.Resting() && !g.NewScore && (TimeAttack || g.ShowScores)
+}
+
+// saveScreenshot copies the screen on the GPU and then reads it back and
+// writes it to path as a PNG in the background, only logging if it goes wrong
+// so the game carries on
+func saveScreenshot(screen *ebiten.Image, path string) {
+	if path == "" {
+		return
+	}
+	frame := ebiten.NewImage(screen.Size())
+	frame.DrawImage(screen, nil)
+	go func() {
+		defer frame.Dispose()
+		bounds := frame.Bounds()
+		shot := image.NewRGBA(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				shot.Set(x, y, frame.At(x, y))
+			}
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			log.Printf("error saving screenshot: %v\n", err)
+			return
+		}
+		defer f.Close()
+		if err := png.Encode(f, shot); err != nil {
+			log.Printf("error saving screenshot: %v\n", err)
+			return
+		}
+		log.Printf("saved screenshot %s\n", path)
+	}()
+}
@@ -0,0 +1,274 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// spectateVersion is the version of the spectator protocol, bump it whenever
+// a change to SpectatorState would confuse an older spectator
+const spectateVersion = 3
+
+// SpectatorState is everything a spectator needs to draw one tick of a game,
+// sent as a line of JSON
+type SpectatorState struct {
+	Version   int
+	Tick      int
+	Rotation  float64
+	MoonAngle float64
+	Wave      int
+	Count     int
+	Score     int
+	GameOver  bool
+	Asteroids []SpectatorAsteroid
+}
+
+// SpectatorAsteroid is where one asteroid is and how it's moving, for
+// spectators
+type SpectatorAsteroid struct {
+	ID        int
+	Angle     float64
+	Distance  float64
+	Speed     float64
+	VX, VY    float64
+	Alive     bool
+	Exploding bool
+}
+
+// SpectatorState is the game's current state for sending to spectators
+func (g *Game) SpectatorState() SpectatorState {
+	s := SpectatorState{
+		Version:   spectateVersion,
+		Tick:      g.Tick,
+		Rotation:  g.Rotation,
+		MoonAngle: g.moonAngle(),
+		Wave:      g.Wave,
+		Count:     g.Count,
+		Score:     g.Score,
+		GameOver:  g.GameOver,
+		Asteroids: make([]SpectatorAsteroid, 0, len(g.Asteroids)),
+	}
+	for _, v := range g.Asteroids {
+		s.Asteroids = append(s.Asteroids, SpectatorAsteroid{
+			ID:        v.ID,
+			Angle:     v.Angle,
+			Distance:  v.Distance,
+			Speed:     v.Speed,
+			VX:        v.VX,
+			VY:        v.VY,
+			Alive:     v.Alive,
+			Exploding: v.Explosion.Exploding,
+		})
+	}
+	return s
+}
+
+// ApplySpectatorState makes the game look like the state a spectator was
+// sent, reusing asteroids it already has by ID
+func (g *Game) ApplySpectatorState(s SpectatorState) {
+	g.Tick = s.Tick
+	g.Rotation = s.Rotation
+	if g.Moon != nil {
+		g.Moon.OrbitAngle = s.MoonAngle
+	}
+	g.Wave = s.Wave
+	g.Count = s.Count
+	g.Score = s.Score
+	g.GameOver = s.GameOver
+
+	known := make(map[int]*Asteroid, len(g.Asteroids))
+	for _, v := range g.Asteroids {
+		known[v.ID] = v
+	}
+	asteroids := make(Asteroids, 0, len(s.Asteroids))
+	for _, sa := range s.Asteroids {
+		v, ok := known[sa.ID]
+		if !ok {
+			v = NewAsteroids(g.Images["asteroid"], g.Images["explosion"], g.Rand, g.Earth.Radius, 1)[0]
+			v.ID = sa.ID
+		}
+		v.Angle = sa.Angle
+		v.Distance = sa.Distance
+		v.Speed = sa.Speed
+		v.VX, v.VY = sa.VX, sa.VY
+		v.Alive = sa.Alive
+		v.Explosion.Exploding = sa.Exploding
+		asteroids = append(asteroids, v)
+	}
+	g.Asteroids = asteroids
+	g.Entities[0] = g.Asteroids
+}
+
+// spectatorBacklog is how many states a spectator can fall behind by before
+// it's dropped, and spectatorTimeout how long one write to it may take
+const (
+	spectatorBacklog = 8
+	spectatorTimeout = time.Second
+)
+
+// A Broadcaster sends the game's state to every spectator watching it
+type Broadcaster struct {
+	mu       sync.Mutex
+	watchers []*watcher
+}
+
+// A watcher is one spectator's connection and the states queued for it, so a
+// slow spectator never holds up the game
+type watcher struct {
+	w      io.WriteCloser
+	states chan []byte
+}
+
+// Broadcast lets spectators connect to l and be sent the game's state
+func Broadcast(l net.Listener) *Broadcaster {
+	b := &Broadcaster{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Printf("stopped accepting spectators: %v\n", err)
+				return
+			}
+			log.Printf("spectator connected from %v\n", conn.RemoteAddr())
+			b.Add(conn)
+		}
+	}()
+	return b
+}
+
+// Add starts sending the game's state to w
+func (b *Broadcaster) Add(w io.WriteCloser) {
+	v := &watcher{w: w, states: make(chan []byte, spectatorBacklog)}
+	b.mu.Lock()
+	b.watchers = append(b.watchers, v)
+	b.mu.Unlock()
+	go b.write(v)
+}
+
+// write sends a watcher its queued states until it's dropped or a write fails
+func (b *Broadcaster) write(v *watcher) {
+	defer v.w.Close()
+	deadline, _ := v.w.(interface{ SetWriteDeadline(time.Time) error })
+	for data := range v.states {
+		if deadline != nil {
+			deadline.SetWriteDeadline(time.Now().Add(spectatorTimeout))
+		}
+		if _, err := v.w.Write(data); err != nil {
+			log.Printf("spectator dropped: %v\n", err)
+			b.drop(v)
+			return
+		}
+	}
+}
+
+// drop stops sending states to a watcher, if it hasn't been dropped already
+func (b *Broadcaster) drop(v *watcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, w := range b.watchers {
+		if w == v {
+			b.watchers = append(b.watchers[:i], b.watchers[i+1:]...)
+			close(v.states)
+			return
+		}
+	}
+}
+
+// Send queues a state for every spectator without waiting for any of them,
+// dropping those too far behind to keep up
+func (b *Broadcaster) Send(s SpectatorState) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		log.Printf("error encoding spectator state: %v\n", err)
+		return
+	}
+	data = append(data, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	watchers := b.watchers[:0]
+	for _, v := range b.watchers {
+		select {
+		case v.states <- data:
+			watchers = append(watchers, v)
+		default:
+			log.Printf("spectator dropped: more than %d states behind\n", spectatorBacklog)
+			close(v.states)
+		}
+	}
+	b.watchers = watchers
+}
+
+// A Spectator follows a broadcast game, keeping the latest state it was sent
+type Spectator struct {
+	mu     sync.Mutex
+	latest SpectatorState
+	ok     bool
+	err    error
+}
+
+// Spectate starts following the game broadcast on r
+func Spectate(r io.Reader) *Spectator {
+	s := &Spectator{}
+	go func() {
+		dec := json.NewDecoder(r)
+		for {
+			var state SpectatorState
+			err := dec.Decode(&state)
+			if err == nil && state.Version != spectateVersion {
+				err = fmt.Errorf("broadcast is protocol version %d, this game speaks %d", state.Version, spectateVersion)
+			}
+			s.mu.Lock()
+			if err != nil {
+				s.err = err
+				s.mu.Unlock()
+				log.Printf("stopped spectating: %v\n", err)
+				return
+			}
+			s.latest, s.ok = state, true
+			s.mu.Unlock()
+		}
+	}()
+	return s
+}
+
+// Latest is the last state the spectator was sent, ok is false until the first
+// one arrives
+func (s *Spectator) Latest() (state SpectatorState, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest, s.ok
+}
+
+// Err is why the spectator stopped following the broadcast, if it has
+func (s *Spectator) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// updateSpectating shows the latest broadcast state, without any of the
+// game's own logic or input: everything is put where the broadcast says and
+// only the sprites and animations move on by themselves
+func (g *Game) updateSpectating() {
+	if state, ok := g.Spectator.Latest(); ok {
+		g.ApplySpectatorState(state)
+	}
+	for _, v := range g.Asteroids {
+		v.locate(g)
+		v.animate(g)
+	}
+	if g.Moon != nil {
+		g.Moon.place(g)
+	}
+	g.Earth.Update(g)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpectateRoundTrip(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 2
+	g.HowMany = 3
+	g.Restart()
+	g.Score = 12
+	g.Rotation = 1.5
+	g.Asteroids[1].Explosion.Exploding = true
+
+	watcher, player := net.Pipe()
+	defer watcher.Close()
+	b := &Broadcaster{}
+	b.Add(player)
+	s := Spectate(watcher)
+	go b.Send(g.SpectatorState())
+
+	var state SpectatorState
+	deadline := time.Now().Add(time.Second)
+	for ok := false; !ok; state, ok = s.Latest() {
+		if time.Now().After(deadline) {
+			t.Fatalf("spectator never got the state: %v", s.Err())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	spec, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.ApplySpectatorState(state)
+	if spec.Score != 12 || spec.Rotation != 1.5 || spec.Wave != 2 || spec.Count != g.Count {
+		t.Errorf("spectator has score %d rotation %v wave %d count %d, want 12, 1.5, 2 and %d",
+			spec.Score, spec.Rotation, spec.Wave, spec.Count, g.Count)
+	}
+	if len(spec.Asteroids) != len(g.Asteroids) {
+		t.Fatalf("spectator has %d asteroids, want %d", len(spec.Asteroids), len(g.Asteroids))
+	}
+	for i, v := range spec.Asteroids {
+		want := g.Asteroids[i]
+		if v.ID != want.ID || v.Angle != want.Angle || v.Distance != want.Distance || v.Explosion.Exploding != want.Explosion.Exploding {
+			t.Errorf("asteroid %d is %+v, want %+v", i, v, want)
+		}
+	}
+
+	// The same asteroids are moved, not replaced, on the next state
+	first := spec.Asteroids[0]
+	state.Asteroids[0].Distance -= 10
+	spec.ApplySpectatorState(state)
+	if spec.Asteroids[0] != first || first.Distance != g.Asteroids[0].Distance-10 {
+		t.Errorf("asteroid replaced or not moved by the next state")
+	}
+}
+
+func TestSpectatingRunsNoLogic(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{}, WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Wave = 1
+	g.HowMany = 2
+	g.Restart()
+	g.Moon.place(g)
+	g.Asteroids[0].Angle = g.Moon.Bearing()
+	g.Asteroids[0].Distance = g.Moon.OrbitRadius(g) - g.Earth.Radius
+	g.Asteroids[1].VX, g.Asteroids[1].VY = 2, -1
+	state := g.SpectatorState()
+
+	spec, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Sounds = &Sounds{}
+	spec.Spectator = &Spectator{latest: state, ok: true}
+	for i := 0; i < 10; i++ {
+		spec.updateSpectating()
+	}
+	if spec.Count != g.Count || spec.Score != g.Score {
+		t.Errorf("spectator has count %d score %d, want %d and %d", spec.Count, spec.Score, g.Count, g.Score)
+	}
+	if a := spec.Asteroids[0]; a.Explosion.Exploding || a.Distance != g.Asteroids[0].Distance {
+		t.Errorf("spectator's moon hit an asteroid by itself")
+	}
+	if a := spec.Asteroids[1]; a.Angle != g.Asteroids[1].Angle || a.Distance != g.Asteroids[1].Distance || a.VX != 2 || a.VY != -1 {
+		t.Errorf("spectator's asteroid moved by itself to %+v", a)
+	}
+
+	// An explosion the broadcast no longer has is stopped
+	state.Asteroids[1].Exploding = true
+	spec.Spectator = &Spectator{latest: state, ok: true}
+	spec.updateSpectating()
+	state.Asteroids[1].Exploding = false
+	spec.Spectator = &Spectator{latest: state, ok: true}
+	spec.updateSpectating()
+	if spec.Asteroids[1].Explosion.Exploding {
+		t.Errorf("spectator kept exploding an asteroid the broadcast didn't")
+	}
+}
+
+func TestSlowSpectator(t *testing.T) {
+	watcher, player := net.Pipe()
+	defer watcher.Close()
+	b := &Broadcaster{}
+	b.Add(player)
+
+	// Nobody reads from the watcher, so sending must neither block nor keep it
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < spectatorBacklog+2; i++ {
+			b.Send(SpectatorState{Version: spectateVersion, Tick: i})
+		}
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second / 2):
+		t.Fatalf("a spectator that isn't reading held up the game")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.watchers) != 0 {
+		t.Errorf("spectator that fell behind was kept")
+	}
+}
+
+func TestSpectateVersion(t *testing.T) {
+	s := Spectate(strings.NewReader(`{"Version": 99}` + "\n"))
+	deadline := time.Now().Add(time.Second)
+	for s.Err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("newer protocol version accepted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := s.Latest(); ok {
+		t.Errorf("state from a newer protocol version kept")
+	}
+}
+
+func TestFrameDumpRoundTrip(t *testing.T) {
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Wave = 3
+	g.HowMany = 4
+	g.Restart()
+	for _, v := range g.Asteroids {
+		v.Update(g)
+	}
+	g.Earth.Update(g)
+	g.Moon.Update(g)
+	g.Score = 7
+	g.Asteroids[2].Explosion.Exploding = true
+	g.Crosshair.Shooting = true
+
+	var buf bytes.Buffer
+	if err := g.WriteFrameDump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var dump FrameDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if want := g.FrameDump(); !reflect.DeepEqual(dump, want) {
+		t.Fatalf("frame dump came back as %+v, want %+v", dump, want)
+	}
+	if len(dump.Asteroids) != 4 || dump.Moon == nil || dump.Laser == nil {
+		t.Fatalf("frame dump is missing objects: %+v", dump)
+	}
+	if a := dump.Asteroids[2]; a.VX != 0 || a.VY != 0 {
+		t.Errorf("exploding asteroid still moving at %v, %v", a.VX, a.VY)
+	}
+	if a := dump.Asteroids[0]; a.ID != g.Asteroids[0].ID || a.X != g.Asteroids[0].Center.X || a.VX == 0 && a.VY == 0 {
+		t.Errorf("asteroid dumped as %+v", a)
+	}
+
+	// The dumped state brings another game back to the same frame
+	other, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.ApplySpectatorState(dump.State)
+	if got := other.SpectatorState(); !reflect.DeepEqual(got, dump.State) {
+		t.Errorf("applying the dump gave %+v, want %+v", got, dump.State)
+	}
+}
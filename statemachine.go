@@ -0,0 +1,114 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A GameState is one of the screens the game can be on
+type GameState int
+
+// The game starts on the title, goes to playing, then to game over and back
+// to playing again for each restart
+const (
+	StateTitle GameState = iota
+	StatePlaying
+	StateGameOver
+)
+
+// String names the state for logging
+func (s GameState) String() string {
+	switch s {
+	case StateTitle:
+		return "title"
+	case StatePlaying:
+		return "playing"
+	case StateGameOver:
+		return "game over"
+	}
+	return fmt.Sprintf("state %d", int(s))
+}
+
+// StateHooks are what a state does when it's entered, every tick, every frame
+// and when it's left, any of them can be nil
+type StateHooks struct {
+	Enter  func()
+	Update func()
+	Draw   func(screen *ebiten.Image)
+	Exit   func()
+}
+
+// A StateMachine keeps track of which state the game is in and only lets it
+// move between states along the transitions it was told are allowed
+type StateMachine struct {
+	current GameState
+	states  map[GameState]StateHooks
+	allowed map[GameState][]GameState
+}
+
+// NewStateMachine makes a state machine sitting in the start state, without
+// calling its Enter hook
+func NewStateMachine(start GameState) *StateMachine {
+	return &StateMachine{
+		current: start,
+		states:  make(map[GameState]StateHooks),
+		allowed: make(map[GameState][]GameState),
+	}
+}
+
+// Add sets the hooks for a state and which states it's allowed to move to
+func (m *StateMachine) Add(s GameState, hooks StateHooks, to ...GameState) {
+	m.states[s] = hooks
+	m.allowed[s] = to
+}
+
+// Current is the state the machine is in
+func (m *StateMachine) Current() GameState {
+	return m.current
+}
+
+// Can reports whether moving from the current state to another is allowed
+func (m *StateMachine) Can(to GameState) bool {
+	for _, s := range m.allowed[m.current] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition leaves the current state and enters another, calling the old
+// state's Exit hook before the new one's Enter hook, or returns an error
+// without changing anything if the move isn't allowed
+func (m *StateMachine) Transition(to GameState) error {
+	if !m.Can(to) {
+		return fmt.Errorf("can't go from %v to %v", m.current, to)
+	}
+	if exit := m.states[m.current].Exit; exit != nil {
+		exit()
+	}
+	m.current = to
+	if enter := m.states[to].Enter; enter != nil {
+		enter()
+	}
+	return nil
+}
+
+// Update runs the current state's Update hook
+func (m *StateMachine) Update() {
+	if update := m.states[m.current].Update; update != nil {
+		update()
+	}
+}
+
+// Draw runs the current state's Draw hook
+func (m *StateMachine) Draw(screen *ebiten.Image) {
+	if draw := m.states[m.current].Draw; draw != nil {
+		draw(screen)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateMachineTransitions(t *testing.T) {
+	m := NewStateMachine(StateTitle)
+	m.Add(StateTitle, StateHooks{}, StatePlaying)
+	m.Add(StatePlaying, StateHooks{}, StateGameOver)
+	m.Add(StateGameOver, StateHooks{}, StatePlaying)
+
+	for _, c := range []struct {
+		to GameState
+		ok bool
+	}{
+		{StateGameOver, false},
+		{StatePlaying, true},
+		{StateTitle, false},
+		{StateGameOver, true},
+		{StateGameOver, false},
+		{StatePlaying, true},
+	} {
+		from := m.Current()
+		err := m.Transition(c.to)
+		if c.ok && err != nil {
+			t.Errorf("%v to %v: %v", from, c.to, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%v to %v allowed", from, c.to)
+		}
+		want := from
+		if c.ok {
+			want = c.to
+		}
+		if m.Current() != want {
+			t.Errorf("%v to %v left machine in %v, want %v", from, c.to, m.Current(), want)
+		}
+	}
+}
+
+func TestStateMachineHooks(t *testing.T) {
+	var calls []string
+	hooks := func(name string) StateHooks {
+		return StateHooks{
+			Enter:  func() { calls = append(calls, "enter "+name) },
+			Update: func() { calls = append(calls, "update "+name) },
+			Exit:   func() { calls = append(calls, "exit "+name) },
+		}
+	}
+	m := NewStateMachine(StateTitle)
+	m.Add(StateTitle, hooks("title"), StatePlaying)
+	m.Add(StatePlaying, hooks("playing"))
+
+	m.Update()
+	if err := m.Transition(StatePlaying); err != nil {
+		t.Fatal(err)
+	}
+	m.Update()
+	m.Transition(StateTitle) // not allowed, no hooks should run
+	m.Draw(nil)              // no draw hook, nothing happens
+
+	want := []string{"update title", "exit title", "enter playing", "update playing"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks ran %q, want %q", calls, want)
+	}
+}
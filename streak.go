@@ -0,0 +1,100 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A Milestone is a survival streak that unlocks a cosmetic reward
+type Milestone struct {
+	Waves    int    // survived in a single run
+	Cosmetic string // kept in the save file, never change it
+	Name     string
+}
+
+// milestones are all the streak rewards, from the shortest streak up
+var milestones = []Milestone{
+	{3, "green", "GREEN CROSSHAIR"},
+	{6, "blue", "BLUE CROSSHAIR"},
+	{10, "gold", "GOLD CROSSHAIR"},
+}
+
+// crosshairColours are the colours the crosshair can be, once unlocked
+var crosshairColours = map[string]color.RGBA{
+	"white": {255, 255, 255, 255},
+	"green": {100, 255, 100, 255},
+	"blue":  {100, 180, 255, 255},
+	"gold":  {255, 210, 60, 255},
+}
+
+// RecordStreak keeps the longest streak of waves survived in a run, and
+// unlocks and returns the cosmetics it's newly earned
+func (s *SaveFile) RecordStreak(waves int) []Milestone {
+	if waves > s.BestStreak {
+		s.BestStreak = waves
+	}
+	var unlocked []Milestone
+	for _, m := range milestones {
+		if !s.HasCosmetic(m.Cosmetic) && s.BestStreak >= m.Waves {
+			s.Cosmetics = append(s.Cosmetics, m.Cosmetic)
+			unlocked = append(unlocked, m)
+		}
+	}
+	return unlocked
+}
+
+// HasCosmetic reports whether the cosmetic with ID id is unlocked, white
+// always is
+func (s *SaveFile) HasCosmetic(id string) bool {
+	if id == "white" {
+		return true
+	}
+	for _, v := range s.Cosmetics {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CrosshairColour is the colour the crosshair should be, the chosen one if
+// it's unlocked or otherwise white
+func (s *SaveFile) CrosshairColour(name string) color.RGBA {
+	if c, ok := crosshairColours[name]; ok && s.HasCosmetic(name) {
+		return c
+	}
+	return crosshairColours["white"]
+}
+
+// StreakProgress describes the best streak and the next milestone to reach
+func (s *SaveFile) StreakProgress() string {
+	for _, m := range milestones {
+		if s.BestStreak < m.Waves {
+			return fmt.Sprintf("BEST STREAK %d  NEXT REWARD AT %d", s.BestStreak, m.Waves)
+		}
+	}
+	return fmt.Sprintf("BEST STREAK %d  ALL REWARDS UNLOCKED", s.BestStreak)
+}
+
+// recordStreak saves the streak so far and pops up a toast for any rewards
+func (g *Game) recordStreak() {
+	before := g.Save.BestStreak
+	unlocked := g.Save.RecordStreak(g.Stats.Waves)
+	if len(unlocked) > 0 {
+		g.Toast = "UNLOCKED: " + unlocked[len(unlocked)-1].Name
+		g.ToastTicks = 3 * ebiten.MaxTPS()
+		g.Crosshair.Colour = g.Save.CrosshairColour(CrosshairColour)
+	}
+	if g.Save.BestStreak != before {
+		if err := g.Save.Write(SaveFileName); err != nil {
+			log.Printf("error writing save file: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"io"
+	"log"
+	"os"
+)
+
+// studyLog is where study mode writes every collision check, nil when it's off
+var studyLog *log.Logger
+
+// startStudy turns on study mode, logging every collision check to the file at
+// path until it's closed
+func startStudy(path string) (io.Closer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	studyLog = log.New(f, "", log.Lmicroseconds)
+	return f, nil
+}
+
+// studyCheck logs a collision check of kind, like "moon" or "shot", between
+// asteroid a and something at center with radius, when study mode is on
+func studyCheck(tick int, kind string, a *Asteroid, center image.Point, radius float64, hit bool) {
+	if studyLog == nil || !a.Alive || a.Explosion.Exploding {
+		return
+	}
+	studyLog.Printf("tick=%d check=%s asteroid=%d at=%d,%d r=%.1f distance=%.1f other=%d,%d r=%.1f hit=%v\n",
+		tick, kind, a.ID, a.Center.X, a.Center.Y, a.Radius, a.Distance, center.X, center.Y, radius, hit)
+}
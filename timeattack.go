@@ -0,0 +1,71 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+)
+
+// timeAttackSpawnGap is how many ticks apart asteroids keep coming in
+// time attack
+var timeAttackSpawnGap = ebiten.MaxTPS() / 2
+
+// timeAttackTicks is how long a time attack run lasts
+func timeAttackTicks() int {
+	return int(TimeAttackSeconds * float64(ebiten.MaxTPS()))
+}
+
+// updateTimeAttack keeps the Earth safe and asteroids coming, and ends the
+// game when the clock runs out
+func (g *Game) updateTimeAttack() {
+	if g.Wave == 0 || g.GameOver {
+		return
+	}
+
+	// The Earth can't be hit, asteroids that reach it just burn up
+	for _, v := range g.Asteroids {
+		if v.Alive && !v.Explosion.Exploding && v.Distance <= 0 && v.Captured == 0 {
+			g.Count-- // reaches the Earth this tick
+		}
+		v.Impacting = false
+	}
+
+	// Spawn on the run's own clock, which stands still on the ticks the
+	// courtesy slow-down skips where g.Tick doesn't
+	if g.TimeLeft%calmSpawnGap(g.Stats.Ticks) == 0 && g.Spawning {
+		g.Spawn(1)
+	}
+
+	g.TimeLeft--
+	if g.TimeLeft <= 0 {
+		log.Println("time up")
+		g.gameOver()
+	}
+}
+
+// recordTimeAttack keeps the score if it's the best time attack yet
+func (g *Game) recordTimeAttack() {
+	if g.Score <= g.Save.TimeAttackBest {
+		return
+	}
+	g.Save.TimeAttackBest = g.Score
+	if err := g.Save.Write(SaveFileName); err != nil {
+		log.Printf("error writing save file: %v\n", err)
+	}
+}
+
+// drawTimeAttack shows the time left big at the top of the screen, and the
+// best score once it's over
+func drawTimeAttack(screen *ebiten.Image, g *Game, face font.Face) {
+	secs := (g.TimeLeft + ebiten.MaxTPS() - 1) / ebiten.MaxTPS()
+	drawTextCentred(screen, fmt.Sprintf("%d:%02d", secs/60, secs%60), face, g.Width/2, g.Height/8)
+	if g.GameOver {
+		drawTextCentred(screen, fmt.Sprintf("BEST %d", g.Save.TimeAttackBest), face, g.Width/2, g.Height/8*6)
+	}
+}
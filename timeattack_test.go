@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+func TestTimeAttackEnds(t *testing.T) {
+	defer func(ta bool, name string) { TimeAttack, SaveFileName = ta, name }(TimeAttack, SaveFileName)
+	TimeAttack = true
+	SaveFileName = ""
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	g.Save = &SaveFile{TimeAttackBest: 3}
+	if err := g.State.Transition(StatePlaying); err != nil {
+		t.Fatal(err)
+	}
+	g.TimeLeft = 2
+	g.Score = 7
+
+	// Asteroids reaching the Earth don't end a time attack
+	g.Asteroids[0].Impacting = true
+	g.updateTimeAttack()
+	if g.Asteroids.Impacting() || g.GameOver {
+		t.Fatalf("impact with time left ended the game")
+	}
+
+	g.updateTimeAttack()
+	if !g.GameOver {
+		t.Fatalf("game not over when time ran out")
+	}
+	if g.NewScore {
+		t.Errorf("time attack score went to the leaderboard")
+	}
+	if g.Save.TimeAttackBest != 7 {
+		t.Errorf("best time attack %d, want 7", g.Save.TimeAttackBest)
+	}
+
+	g.updateTimeAttack()
+	if g.TimeLeft != 0 {
+		t.Errorf("clock kept running after game over, %d left", g.TimeLeft)
+	}
+}
+
+func TestTimeAttackBurnUp(t *testing.T) {
+	defer func(ta bool, name string) { TimeAttack, SaveFileName = ta, name }(TimeAttack, SaveFileName)
+	TimeAttack = true
+	SaveFileName = ""
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	if err := g.State.Transition(StatePlaying); err != nil {
+		t.Fatal(err)
+	}
+	between := 10*calmSpawnGap(g.Stats.Ticks) + 1 // time left between spawns
+	g.TimeLeft = between
+	count := g.Count
+	a := g.Asteroids[0]
+	a.Distance = 0
+
+	// Burning up at the Earth takes it off the count, only the once
+	for i := 0; i < 10; i++ {
+		g.updateTimeAttack()
+		a.Update(g)
+		g.TimeLeft = between
+	}
+	if g.Count != count-1 {
+		t.Errorf("count %d after an asteroid burnt up, want %d", g.Count, count-1)
+	}
+}
+
+func TestTimeAttackSpawnsOnRunClock(t *testing.T) {
+	defer func(ta bool, name string) { TimeAttack, SaveFileName = ta, name }(TimeAttack, SaveFileName)
+	TimeAttack = true
+	SaveFileName = ""
+
+	g, err := NewGame(1280, 960, assetLoader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Sounds = &Sounds{}
+	if err := g.State.Transition(StatePlaying); err != nil {
+		t.Fatal(err)
+	}
+	gap := calmSpawnGap(g.Stats.Ticks)
+	g.TimeLeft = 10*gap + 1
+	before := len(g.Asteroids)
+
+	// Ticks skipped by the courtesy slow-down move g.Tick but not the run's
+	// clock, so they mustn't shift when asteroids come in
+	for i := 0; i < 2*gap; i++ {
+		g.Tick += 3
+		g.updateTimeAttack()
+	}
+	if n := len(g.Asteroids) - before; n != 2 {
+		t.Errorf("%d asteroids spawned in %d ticks, want 2", n, 2*gap)
+	}
+}
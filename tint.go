@@ -0,0 +1,57 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// parseTints reads a ramp of tints written as space separated hex colours,
+// like "ffffff ffeedd"
+func parseTints(s string) ([]color.RGBA, error) {
+	var tints []color.RGBA
+	for _, v := range strings.Fields(s) {
+		n, err := strconv.ParseUint(strings.TrimPrefix(v, "#"), 16, 32)
+		if err != nil || len(strings.TrimPrefix(v, "#")) != 6 {
+			return nil, fmt.Errorf("bad tint %q, want a colour like ffeedd", v)
+		}
+		tints = append(tints, color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255})
+	}
+	return tints, nil
+}
+
+// waveTint is the tint of asteroids in a wave, one step along the ramp for
+// each wave and staying at the end of it once the waves go past that
+func waveTint(wave int, ramp []color.RGBA) color.RGBA {
+	if len(ramp) == 0 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	i := wave - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(ramp) {
+		i = len(ramp) - 1
+	}
+	return ramp[i]
+}
+
+// tintAsteroids tints a wave of asteroids by how far into the game it is
+func tintAsteroids(as Asteroids, wave int) {
+	ramp, err := parseTints(WaveTints)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	c := waveTint(wave, ramp)
+	for _, v := range as {
+		v.Op.ColorM.Reset()
+		v.Op.ColorM.Scale(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, 1)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Siôn le Roux.  All rights reserved.
+// Use of this source code is subject to an MIT-style
+// licence which can be found in the LICENSE file.
+
+package main
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// tractorPressed is shorthand for when the tractor key, T unless rebound, (or
+// the third gamepad button) has just been pressed
+func tractorPressed() bool {
+	for _, id := range ebiten.GamepadIDs() {
+		if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton2) {
+			return true
+		}
+	}
+	return inpututil.IsKeyJustPressed(controls.Keys[ActionTractor])
+}
+
+// Tractor uses up the wave's tractor beam to hold the asteroid closest to the
+// Earth in place for TractorSeconds, and returns it, or nil if there wasn't
+// one to catch
+func (g *Game) Tractor() *Asteroid {
+	if !g.TractorOK || g.Asteroids.Captured() != nil {
+		return nil
+	}
+	var nearest *Asteroid
+	for _, v := range g.Asteroids {
+		if v.Alive && !v.Explosion.Exploding && (nearest == nil || v.Distance < nearest.Distance) {
+			nearest = v
+		}
+	}
+	if nearest == nil {
+		return nil
+	}
+	g.TractorOK = false
+	nearest.Captured = int(TractorSeconds * float64(ebiten.MaxTPS()))
+	log.Println("tractor beam caught an asteroid")
+	return nearest
+}
+
+// Captured is the asteroid being held by the tractor beam, if there is one
+func (as Asteroids) Captured() *Asteroid {
+	for _, v := range as {
+		if v.Captured > 0 && v.Alive {
+			return v
+		}
+	}
+	return nil
+}
+
+// drawTractorBeam draws a line from the Earth to the captured asteroid
+func drawTractorBeam(screen *ebiten.Image, g *Game) {
+	v := g.Asteroids.Captured()
+	if v == nil || v.Explosion.Exploding {
+		return
+	}
+	ebitenutil.DrawLine(screen,
+		float64(g.Earth.Center.X), float64(g.Earth.Center.Y),
+		float64(v.Center.X), float64(v.Center.Y),
+		color.RGBA{0x40, 0xc0, 0xff, 0xc0},
+	)
+}